@@ -12,68 +12,60 @@ import (
 	"github.com/gin-gonic/gin"
 	"tracer/opentelemetry"
 
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	addr = ":8080"
-)
 
-// tracerProvider returns an OpenTelemetry TracerProvider configured to use
-// the Jaeger exporter that will send spans to the provided url. The returned
-// TracerProvider will also use a Resource configured with all the information
-// about the application.
-func tracerProvider(url string) (*tracesdk.TracerProvider, error) {
-	// Create the Jaeger exporter
-	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
-	if err != nil {
-		return nil, err
-	}
-	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithSampler(tracesdk.AlwaysSample()),
-		tracesdk.WithBatcher(exp),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("opentelemetry-app-test"), // 服务名
-			semconv.ServiceVersionKey.String("0.0.1"),
-			attribute.String("environment", "test"),
-		)),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	return tp, nil
-}
+	// httpClient traces the outgoing requests
+	httpClient = &http.Client{Transport: opentelemetry.Transport(nil)}
+)
 
 func main() {
-	tp, err := tracerProvider("http://localhost:14268/api/traces")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdown, err := opentelemetry.Init(ctx, opentelemetry.Config{
+		ServiceName:        "opentelemetry-app-test", // 服务名
+		ResourceAttributes: map[string]string{"environment": "test"},
+		Exporter: opentelemetry.ExporterConfig{
+			Type:     opentelemetry.ExporterJaegerCollector,
+			Endpoint: "http://localhost:14268/api/traces",
+		},
+		Sampler:     opentelemetry.SamplerConfig{Type: "always_on"},
+		Propagators: []string{"tracecontext", "baggage"},
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	// Cleanly shutdown and flush telemetry when the application exits.
 	defer func(ctx context.Context) {
 		// Do not make the application hang when it is shutdown.
 		ctx, cancel = context.WithTimeout(ctx, time.Second*5)
 		defer cancel()
-		if err := tp.Shutdown(ctx); err != nil {
+		if err := shutdown(ctx); err != nil {
 			log.Fatal(err)
 		}
 	}(ctx)
 
+	mp, metricsHandler, err := opentelemetry.MeterProviderWithPrometheusOptions("opentelemetry-app-test", nil,
+		opentelemetry.WithResourceAttributes(attribute.String("environment", "test")))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mp.Shutdown(context.Background())
+
 	engine := gin.New()
 
 	engine.Use(logger.SetLogger())
+	// the scrapes are neither traced nor counted
+	engine.GET("/metrics", gin.WrapH(metricsHandler))
 	//engine.Use(otelgin.Middleware("serverTest"))
-	engine.Use(opentelemetry.Tracing("serverTest"))
+	engine.Use(opentelemetry.TracingWithOptions("serverTest", opentelemetry.WithMetrics(mp)))
+	engine.Use(opentelemetry.Logging(nil))
 	engine.GET("/", indexHandler)
 	engine.GET("/home", homeHandler)
 	engine.GET("/home/:id", homeHandler)
@@ -94,11 +86,12 @@ func serviceHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	span := trace.SpanFromContext(ctx)
 
-	dbReq, _ := http.NewRequest("GET", "http://localhost:8080/db", nil)
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(dbReq.Header))
-	if _, err := http.DefaultClient.Do(dbReq); err != nil {
+	dbReq, _ := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/db", nil)
+	if resp, err := httpClient.Do(dbReq); err != nil {
 		span.RecordError(err)
-		attribute.String("请求 /db error", err.Error())
+		span.SetAttributes(attribute.String("请求 /db error", err.Error()))
+	} else {
+		resp.Body.Close()
 	}
 	time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
 }
@@ -110,32 +103,33 @@ func homeHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	span := trace.SpanFromContext(ctx)
 
-	asyncReq, _ := http.NewRequest("GET", "http://localhost:8080/async", nil)
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(asyncReq.Header))
-	go func() {
-		if _, err := http.DefaultClient.Do(asyncReq); err != nil {
-			span.RecordError(err)
-			span.SetAttributes(attribute.String("请求 /async error", err.Error()))
+	// the request span and context end with the request, the background work gets its own
+	opentelemetry.Go(ctx, "/async", func(ctx context.Context) error {
+		asyncReq, _ := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/async", nil)
+		resp, err := httpClient.Do(asyncReq)
+		if err != nil {
+			return err
 		}
-	}()
+		return resp.Body.Close()
+	})
 
 	time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
 
-	syncReq, _ := http.NewRequest("GET", "http://localhost:8080/service", nil)
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(syncReq.Header))
-	if _, err := http.DefaultClient.Do(syncReq); err != nil {
+	syncReq, _ := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/service", nil)
+	if resp, err := httpClient.Do(syncReq); err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("请求 /service error", err.Error()))
+	} else {
+		resp.Body.Close()
 	}
 
-	ctx, span = otel.Tracer("home").Start(ctx, "ping-baidu", trace.WithSpanKind(trace.SpanKindClient))
-	bdReq, _ := http.NewRequest("GET", "https://www.baidu.com", nil)
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(bdReq.Header))
-	if _, err := http.DefaultClient.Do(bdReq); err != nil {
+	bdReq, _ := http.NewRequestWithContext(ctx, "GET", "https://www.baidu.com", nil)
+	if resp, err := httpClient.Do(bdReq); err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("ping baidu error", err.Error()))
+	} else {
+		resp.Body.Close()
 	}
-	span.End()
 
 	c.String(200, "请求结束！")
 }
@@ -0,0 +1,78 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Go runs fn in a new goroutine within an internal span named name, a child of the span of ctx,
+// or a new trace linked to it with WithLink. fn gets a detached ctx which keeps the span, baggage
+// and values of ctx but is never canceled, so the work outlives the request that started it.
+// A panic of fn is recovered and recorded in the span, the returned channel receives the error of fn.
+//
+//	opentelemetry.Go(c.Request.Context(), "send-email", func(ctx context.Context) error {
+//		return mailer.Send(ctx, to, body)
+//	})
+func Go(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...Option) <-chan error {
+	tracer := NewTracer(trace.SpanKindInternal, opts...)
+
+	var startOpts []trace.SpanStartOption
+	if tracer.opt.link {
+		startOpts = append(startOpts, trace.WithNewRoot())
+		if parent := trace.SpanContextFromContext(ctx); parent.IsValid() {
+			startOpts = append(startOpts, trace.WithLinks(trace.Link{SpanContext: parent}))
+		}
+	}
+	// start the span before returning, so it begins while the parent is still alive
+	ctx, span := tracer.Start(Detach(ctx), name, nil, startOpts...)
+
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				span.RecordError(err, trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, err.Error())
+				span.End()
+			} else {
+				tracer.End(ctx, span, nil, err)
+			}
+			errc <- err
+			close(errc)
+		}()
+
+		err = fn(ctx)
+	}()
+	return errc
+}
+
+// Detach returns a context with the values of ctx, including its span and baggage,
+// which is never canceled and has no deadline.
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
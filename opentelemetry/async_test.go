@@ -0,0 +1,75 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("Go", func() {
+	var recorder *oteltest.Recorder
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("outlives the parent context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		ctx, parent := NewTracer(trace.SpanKindServer).Start(ctx, "request", nil)
+		start := make(chan struct{})
+		errc := Go(ctx, "async", func(ctx context.Context) error {
+			<-start
+			Expect(ctx.Err()).ShouldNot(HaveOccurred())
+			return nil
+		})
+		// the request ends before the background work
+		parent.End()
+		cancel()
+		close(start)
+
+		Expect(<-errc).ShouldNot(HaveOccurred())
+		Expect(recorder).Should(oteltest.HaveSpan("async",
+			oteltest.HaveKind(trace.SpanKindInternal),
+			oteltest.HaveStatus(codes.Ok),
+			oteltest.BeChildOf(parent),
+		))
+	})
+
+	It("links the span", func() {
+		ctx, parent := NewTracer(trace.SpanKindServer).Start(context.Background(), "request", nil)
+		parent.End()
+		Expect(<-Go(ctx, "async", func(context.Context) error {
+			return errors.New("failed")
+		}, WithLink())).Should(MatchError("failed"))
+
+		span, ok := recorder.Span("async")
+		Expect(ok).Should(BeTrue())
+		Expect(span.Parent.IsValid()).Should(BeFalse())
+		Expect(span.Links).Should(HaveLen(1))
+		Expect(span.Links[0].SpanContext.SpanID()).Should(Equal(parent.SpanContext().SpanID()))
+		Expect(recorder).Should(oteltest.HaveSpan("async", oteltest.HaveStatus(codes.Error, "failed")))
+	})
+
+	It("recovers panics", func() {
+		Expect(<-Go(context.Background(), "async", func(context.Context) error {
+			panic("boom")
+		})).Should(MatchError("panic: boom"))
+
+		span, ok := recorder.Span("async")
+		Expect(ok).Should(BeTrue())
+		Expect(span.Status.Code).Should(Equal(codes.Error))
+		Expect(span.Status.Description).Should(Equal("panic: boom"))
+		Expect(span.Events).Should(HaveLen(1))
+		Expect(span.Events[0].Name).Should(Equal("exception"))
+	})
+})
@@ -0,0 +1,192 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Init sets up tracing from cfg: it creates the exporter, sampler, propagators and resource,
+// sets the tracer provider and propagator globals and returns the function flushing pending
+// spans and releasing everything, call it before the application exits.
+// The fields left unset are read from the OTEL_* environment variables, see ConfigFromEnv, so a Config built
+// in code can still be tuned or disabled by the deployment. Nothing is set up if cfg is disabled, the
+// returned shutdown then does nothing.
+//
+//	cfg, err := opentelemetry.LoadConfig("tracing.yaml")
+//	shutdown, err := opentelemetry.Init(ctx, cfg)
+//	defer shutdown(context.Background())
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	// don't fill the attributes map of the caller
+	attrs := make(map[string]string, len(cfg.ResourceAttributes))
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	cfg.ResourceAttributes = attrs
+	cfg.applyEnv()
+
+	if cfg.Disabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.ServiceName == "" {
+		return nil, fmt.Errorf("tracing service name is empty")
+	}
+
+	propagator, err := NewPropagator(cfg.Propagators...)
+	if err != nil {
+		return nil, err
+	}
+	sampler, closeSampler, err := newConfigSampler(cfg.ServiceName, cfg.Sampler)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := newConfigExporter(ctx, cfg.Exporter)
+	if err != nil {
+		closeSampler()
+		return nil, err
+	}
+
+	opts := []ProviderOption{
+		WithServiceVersion(cfg.ServiceVersion),
+		WithResourceAttributes(configResourceAttributes(cfg.ResourceAttributes)...),
+		WithBatchOptions(cfg.Batch.options()...),
+	}
+	if limits, ok := cfg.SpanLimits.limits(); ok {
+		opts = append(opts, WithSpanLimits(limits))
+	}
+	if sampler != nil {
+		opts = append(opts, WithSampler(sampler))
+	}
+	tp, err := NewTracerProvider(cfg.ServiceName, exp, opts...)
+	if err != nil {
+		closeSampler()
+		return nil, err
+	}
+	otel.SetTextMapPropagator(propagator)
+	setDefaultPropagator(propagator)
+
+	return func(ctx context.Context) error {
+		defer closeSampler()
+		return tp.Shutdown(ctx)
+	}, nil
+}
+
+// newConfigSampler returns a nil sampler if no type is set so the provider reads it from the environment
+func newConfigSampler(serviceName string, cfg SamplerConfig) (tracesdk.Sampler, func(), error) {
+	switch cfg.Type {
+	case "":
+		return nil, func() {}, nil
+	case "jaeger_remote", "parentbased_jaeger_remote":
+		var opts []RemoteSamplerOption
+		if cfg.ServerURL != "" {
+			opts = append(opts, WithSamplingServerURL(cfg.ServerURL))
+		}
+		if cfg.RefreshInterval > 0 {
+			opts = append(opts, WithSamplingRefreshInterval(time.Duration(cfg.RefreshInterval)))
+		}
+		if cfg.Param > 0 {
+			opts = append(opts, WithInitialSampler(tracesdk.TraceIDRatioBased(cfg.Param)))
+		}
+		remote := NewRemoteSampler(serviceName, opts...)
+		if cfg.Type == "parentbased_jaeger_remote" {
+			return tracesdk.ParentBased(remote), remote.Close, nil
+		}
+		return remote, remote.Close, nil
+	default:
+		sampler, err := NewSampler(cfg.Type, cfg.Param)
+		return sampler, func() {}, err
+	}
+}
+
+// newConfigExporter returns a nil exporter for the none type
+func newConfigExporter(ctx context.Context, cfg ExporterConfig) (tracesdk.SpanExporter, error) {
+	switch cfg.Type {
+	case ExporterJaegerCollector, "":
+		var opts []jaeger.CollectorEndpointOption
+		if cfg.Endpoint != "" {
+			opts = append(opts, jaeger.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Username != "" {
+			opts = append(opts, jaeger.WithUsername(cfg.Username), jaeger.WithPassword(cfg.Password))
+		}
+		return jaeger.New(jaeger.WithCollectorEndpoint(opts...))
+	case ExporterJaegerAgent:
+		var opts []jaeger.AgentEndpointOption
+		if cfg.Endpoint != "" {
+			host, port, err := net.SplitHostPort(cfg.Endpoint)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, jaeger.WithAgentHost(host), jaeger.WithAgentPort(port))
+		}
+		return jaeger.New(jaeger.WithAgentEndpoint(opts...))
+	case ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.Compression != "" {
+			opts = append(opts, otlptracegrpc.WithCompressor(cfg.Compression))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracegrpc.WithTimeout(time.Duration(cfg.Timeout)))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		var opts []OTLPHTTPOption
+		if cfg.Endpoint != "" {
+			opts = append(opts, WithHTTPEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, WithHTTPInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, WithHTTPHeaders(cfg.Headers))
+		}
+		if cfg.Compression == "gzip" {
+			opts = append(opts, WithHTTPGzip())
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, WithHTTPTimeout(time.Duration(cfg.Timeout)))
+		}
+		switch cfg.Encoding {
+		case "", "protobuf":
+		case "json":
+			opts = append(opts, WithHTTPEncoding(OTLPEncodingJSON))
+		default:
+			return nil, fmt.Errorf("unsupported otlp http encoding: %q", cfg.Encoding)
+		}
+		return otlptrace.New(ctx, newOTLPHTTPClient(opts...))
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported exporter type: %q", cfg.Type)
+	}
+}
+
+// configResourceAttributes drops service.name and service.version, the config service name and version win over them
+func configResourceAttributes(attrs map[string]string) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		if k == string(semconv.ServiceNameKey) || k == string(semconv.ServiceVersionKey) {
+			continue
+		}
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	return kvs
+}
@@ -0,0 +1,346 @@
+package opentelemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	envServiceName        = "OTEL_SERVICE_NAME"
+	envResourceAttributes = "OTEL_RESOURCE_ATTRIBUTES"
+	envTracesExporter     = "OTEL_TRACES_EXPORTER"
+	envPropagators        = "OTEL_PROPAGATORS"
+	envSDKDisabled        = "OTEL_SDK_DISABLED"
+	envTraceService       = "TRACE_SERVICE_NAME" //same as the opentracing package
+
+	envOTLPProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	envOTLPTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	envJaegerProtocol     = "OTEL_EXPORTER_JAEGER_PROTOCOL"
+
+	envBSPScheduleDelay      = "OTEL_BSP_SCHEDULE_DELAY"
+	envBSPExportTimeout      = "OTEL_BSP_EXPORT_TIMEOUT"
+	envBSPMaxQueueSize       = "OTEL_BSP_MAX_QUEUE_SIZE"
+	envBSPMaxExportBatchSize = "OTEL_BSP_MAX_EXPORT_BATCH_SIZE"
+
+	envAttributeValueLengthLimit     = "OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT"
+	envAttributeCountLimit           = "OTEL_ATTRIBUTE_COUNT_LIMIT"
+	envSpanAttributeValueLengthLimit = "OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT"
+	envSpanAttributeCountLimit       = "OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT"
+	envSpanEventCountLimit           = "OTEL_SPAN_EVENT_COUNT_LIMIT"
+	envSpanLinkCountLimit            = "OTEL_SPAN_LINK_COUNT_LIMIT"
+	envEventAttributeCountLimit      = "OTEL_EVENT_ATTRIBUTE_COUNT_LIMIT"
+	envLinkAttributeCountLimit       = "OTEL_LINK_ATTRIBUTE_COUNT_LIMIT"
+)
+
+// exporter types of ExporterConfig
+const (
+	ExporterJaegerCollector = "jaeger_collector"
+	ExporterJaegerAgent     = "jaeger_agent"
+	ExporterOTLPGRPC        = "otlp_grpc"
+	ExporterOTLPHTTP        = "otlp_http"
+	ExporterNone            = "none"
+)
+
+// Config is the tracing bootstrap configuration of Init
+type Config struct {
+	ServiceName        string            `json:"service_name" yaml:"service_name"`
+	ServiceVersion     string            `json:"service_version" yaml:"service_version"` //see WithServiceVersion
+	ResourceAttributes map[string]string `json:"resource_attributes" yaml:"resource_attributes"`
+	Exporter           ExporterConfig    `json:"exporter" yaml:"exporter"`
+	Sampler            SamplerConfig     `json:"sampler" yaml:"sampler"`
+	Propagators        []string          `json:"propagators" yaml:"propagators"` //see NewPropagator
+	Batch              BatchConfig       `json:"batch" yaml:"batch"`
+	SpanLimits         SpanLimitsConfig  `json:"span_limits" yaml:"span_limits"`
+	Disabled           bool              `json:"disabled" yaml:"disabled"` //Init does nothing if set
+}
+
+// ExporterConfig selects where spans are sent
+type ExporterConfig struct {
+	Type        string            `json:"type" yaml:"type"`         //jaeger_collector(default)|jaeger_agent|otlp_grpc|otlp_http|none
+	Endpoint    string            `json:"endpoint" yaml:"endpoint"` //collector URL for jaeger_collector, host:port otherwise
+	Insecure    bool              `json:"insecure" yaml:"insecure"` //plain text for otlp_grpc and otlp_http
+	Headers     map[string]string `json:"headers" yaml:"headers"`   //otlp_grpc and otlp_http only
+	Compression string            `json:"compression" yaml:"compression"`
+	Timeout     Duration          `json:"timeout" yaml:"timeout"`
+	Encoding    string            `json:"encoding" yaml:"encoding"` //protobuf|json, otlp_http only
+	Username    string            `json:"username" yaml:"username"` //jaeger_collector only
+	Password    string            `json:"password" yaml:"password"` //jaeger_collector only
+}
+
+// SamplerConfig selects which traces are recorded
+type SamplerConfig struct {
+	Type            string   `json:"type" yaml:"type"` //see NewSampler, jaeger_remote or parentbased_jaeger_remote
+	Param           float64  `json:"param" yaml:"param"`
+	ServerURL       string   `json:"server_url" yaml:"server_url"` //jaeger_remote only
+	RefreshInterval Duration `json:"refresh_interval" yaml:"refresh_interval"`
+}
+
+// BatchConfig tunes the batch span processor, zero values keep the SDK defaults
+type BatchConfig struct {
+	ScheduleDelay      Duration `json:"schedule_delay" yaml:"schedule_delay"`
+	ExportTimeout      Duration `json:"export_timeout" yaml:"export_timeout"`
+	MaxQueueSize       int      `json:"max_queue_size" yaml:"max_queue_size"`
+	MaxExportBatchSize int      `json:"max_export_batch_size" yaml:"max_export_batch_size"`
+}
+
+func (c BatchConfig) options() []tracesdk.BatchSpanProcessorOption {
+	var opts []tracesdk.BatchSpanProcessorOption
+	if c.ScheduleDelay > 0 {
+		opts = append(opts, tracesdk.WithBatchTimeout(time.Duration(c.ScheduleDelay)))
+	}
+	if c.ExportTimeout > 0 {
+		opts = append(opts, tracesdk.WithExportTimeout(time.Duration(c.ExportTimeout)))
+	}
+	if c.MaxQueueSize > 0 {
+		opts = append(opts, tracesdk.WithMaxQueueSize(c.MaxQueueSize))
+	}
+	if c.MaxExportBatchSize > 0 {
+		opts = append(opts, tracesdk.WithMaxExportBatchSize(c.MaxExportBatchSize))
+	}
+	return opts
+}
+
+// SpanLimitsConfig bounds what a span records, zero values keep the SDK defaults
+type SpanLimitsConfig struct {
+	AttributeValueLength   int `json:"attribute_value_length" yaml:"attribute_value_length"`
+	AttributeCount         int `json:"attribute_count" yaml:"attribute_count"`
+	EventCount             int `json:"event_count" yaml:"event_count"`
+	LinkCount              int `json:"link_count" yaml:"link_count"`
+	AttributePerEventCount int `json:"attribute_per_event_count" yaml:"attribute_per_event_count"`
+	AttributePerLinkCount  int `json:"attribute_per_link_count" yaml:"attribute_per_link_count"`
+}
+
+func (c SpanLimitsConfig) limits() (tracesdk.SpanLimits, bool) {
+	return tracesdk.SpanLimits{
+		AttributeValueLengthLimit:   c.AttributeValueLength,
+		AttributeCountLimit:         c.AttributeCount,
+		EventCountLimit:             c.EventCount,
+		LinkCountLimit:              c.LinkCount,
+		AttributePerEventCountLimit: c.AttributePerEventCount,
+		AttributePerLinkCountLimit:  c.AttributePerLinkCount,
+	}, c != SpanLimitsConfig{}
+}
+
+// Duration is a time.Duration written as a string like "5s" in config files
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// LoadConfig reads the config from a YAML (.yaml, .yml) or JSON (.json) file,
+// unset fields are then filled from the environment like ConfigFromEnv.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		err = fmt.Errorf("unsupported config file type: %q", ext)
+	}
+	if err != nil {
+		return cfg, err
+	}
+	cfg.applyEnv()
+
+	return cfg, nil
+}
+
+// ConfigFromEnv reads the config from the OpenTelemetry SDK environment variables:
+//
+// - OTEL_SERVICE_NAME, TRACE_SERVICE_NAME is the service name.
+// - OTEL_RESOURCE_ATTRIBUTES is a comma separated list of key=value resource attributes.
+// - OTEL_TRACES_EXPORTER is otlp, jaeger, none or an exporter type of ExporterConfig.
+// - OTEL_EXPORTER_OTLP_(TRACES_)PROTOCOL selects grpc, http/protobuf or http/json for otlp.
+// - OTEL_EXPORTER_JAEGER_PROTOCOL selects the agent for udp/thrift.compact and udp/thrift.binary.
+// - OTEL_PROPAGATORS is a comma separated list of propagators.
+// - OTEL_SDK_DISABLED=true turns Init into a no-op.
+// - OTEL_BSP_SCHEDULE_DELAY, OTEL_BSP_EXPORT_TIMEOUT (milliseconds), OTEL_BSP_MAX_QUEUE_SIZE
+// and OTEL_BSP_MAX_EXPORT_BATCH_SIZE tune the batch span processor.
+// - OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT, OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT, OTEL_SPAN_EVENT_COUNT_LIMIT,
+// OTEL_SPAN_LINK_COUNT_LIMIT, OTEL_EVENT_ATTRIBUTE_COUNT_LIMIT and OTEL_LINK_ATTRIBUTE_COUNT_LIMIT
+// bound the spans, OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT and OTEL_ATTRIBUTE_COUNT_LIMIT are their fallbacks.
+//
+// The sampler and the exporter endpoints read their own OTEL_* variables.
+// Invalid values are reported to the otel error handler and ignored.
+func ConfigFromEnv() Config {
+	var cfg Config
+	cfg.applyEnv()
+
+	return cfg
+}
+
+// applyEnv fills unset fields from the environment, the values set in code or files win
+func (c *Config) applyEnv() {
+	if v := os.Getenv(envResourceAttributes); v != "" {
+		attrs := parseResourceAttributes(v)
+		if c.ResourceAttributes == nil && len(attrs) > 0 {
+			c.ResourceAttributes = make(map[string]string, len(attrs))
+		}
+		for k, v := range attrs {
+			if _, ok := c.ResourceAttributes[k]; !ok {
+				c.ResourceAttributes[k] = v
+			}
+		}
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = firstEnv(envServiceName, envTraceService)
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = c.ResourceAttributes["service.name"]
+	}
+	if c.ServiceVersion == "" {
+		c.ServiceVersion = c.ResourceAttributes["service.version"]
+	}
+	if c.Exporter.Type == "" {
+		c.Exporter.Type = exporterTypeFromEnv(os.Getenv(envTracesExporter))
+	}
+	if c.Exporter.Type == ExporterOTLPHTTP && c.Exporter.Encoding == "" &&
+		firstEnv(envOTLPTracesProtocol, envOTLPProtocol) == "http/json" {
+		c.Exporter.Encoding = "json"
+	}
+	if v := os.Getenv(envPropagators); len(c.Propagators) == 0 && v != "" {
+		c.Propagators = strings.Split(v, ",")
+	}
+	if v := os.Getenv(envSDKDisabled); v != "" && !c.Disabled {
+		c.Disabled = strings.EqualFold(strings.TrimSpace(v), "true")
+	}
+
+	envMillis(&c.Batch.ScheduleDelay, envBSPScheduleDelay)
+	envMillis(&c.Batch.ExportTimeout, envBSPExportTimeout)
+	envInt(&c.Batch.MaxQueueSize, envBSPMaxQueueSize)
+	envInt(&c.Batch.MaxExportBatchSize, envBSPMaxExportBatchSize)
+
+	envInt(&c.SpanLimits.AttributeValueLength, envSpanAttributeValueLengthLimit, envAttributeValueLengthLimit)
+	envInt(&c.SpanLimits.AttributeCount, envSpanAttributeCountLimit, envAttributeCountLimit)
+	envInt(&c.SpanLimits.EventCount, envSpanEventCountLimit)
+	envInt(&c.SpanLimits.LinkCount, envSpanLinkCountLimit)
+	envInt(&c.SpanLimits.AttributePerEventCount, envEventAttributeCountLimit, envAttributeCountLimit)
+	envInt(&c.SpanLimits.AttributePerLinkCount, envLinkAttributeCountLimit, envAttributeCountLimit)
+}
+
+// exporterTypeFromEnv maps the OTEL_TRACES_EXPORTER values to the exporter types,
+// only the first of a comma separated list is used
+func exporterTypeFromEnv(v string) string {
+	v, _, _ = cutString(v, ",")
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "otlp":
+		switch protocol := firstEnv(envOTLPTracesProtocol, envOTLPProtocol); protocol {
+		case "http/protobuf", "http/json":
+			return ExporterOTLPHTTP
+		default:
+			return ExporterOTLPGRPC
+		}
+	case "jaeger":
+		if strings.HasPrefix(os.Getenv(envJaegerProtocol), "udp/") {
+			return ExporterJaegerAgent
+		}
+		return ExporterJaegerCollector
+	default:
+		return v
+	}
+}
+
+// parseResourceAttributes parses the W3C baggage like key1=value1,key2=value2 list,
+// malformed members are dropped
+func parseResourceAttributes(v string) map[string]string {
+	attrs := make(map[string]string)
+	for _, member := range strings.Split(v, ",") {
+		key, value, ok := cutString(member, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			otel.Handle(fmt.Errorf("invalid %s member: %q", envResourceAttributes, member))
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			otel.Handle(fmt.Errorf("invalid %s member: %q: %w", envResourceAttributes, member, err))
+			continue
+		}
+		attrs[key] = value
+	}
+	return attrs
+}
+
+// envInt sets an unset *dst from the first set variable of names
+func envInt(dst *int, names ...string) {
+	if *dst != 0 {
+		return
+	}
+	for _, name := range names {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			otel.Handle(fmt.Errorf("invalid %s: %w", name, err))
+			continue
+		}
+		*dst = n
+		return
+	}
+}
+
+// envMillis sets an unset *dst from a variable holding milliseconds
+func envMillis(dst *Duration, name string) {
+	var ms int
+	envInt(&ms, name)
+	if *dst == 0 && ms > 0 {
+		*dst = Duration(time.Duration(ms) * time.Millisecond)
+	}
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,247 @@
+package opentelemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("Config", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "config")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).Should(Succeed())
+	})
+
+	It("LoadConfig yaml succeed", func() {
+		path := filepath.Join(dir, "tracing.yaml")
+		Expect(os.WriteFile(path, []byte(`
+service_name: yaml-service
+resource_attributes:
+  team: infra
+exporter:
+  type: otlp_http
+  endpoint: collector:4318
+  timeout: 5s
+  headers:
+    Authentication: token
+sampler:
+  type: parentbased_traceidratio
+  param: 0.5
+propagators: [tracecontext, b3]
+`), 0o600)).Should(Succeed())
+
+		cfg, err := LoadConfig(path)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cfg.ServiceName).Should(Equal("yaml-service"))
+		Expect(cfg.ResourceAttributes).Should(HaveKeyWithValue("team", "infra"))
+		Expect(cfg.Exporter.Type).Should(Equal(ExporterOTLPHTTP))
+		Expect(time.Duration(cfg.Exporter.Timeout)).Should(Equal(5 * time.Second))
+		Expect(cfg.Exporter.Headers).Should(HaveKeyWithValue("Authentication", "token"))
+		Expect(cfg.Sampler.Param).Should(Equal(0.5))
+		Expect(cfg.Propagators).Should(Equal([]string{"tracecontext", "b3"}))
+	})
+
+	It("LoadConfig json succeed", func() {
+		path := filepath.Join(dir, "tracing.json")
+		Expect(os.WriteFile(path, []byte(`{"service_name": "json-service", "exporter": {"type": "none", "timeout": "1m"}}`), 0o600)).Should(Succeed())
+
+		cfg, err := LoadConfig(path)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cfg.ServiceName).Should(Equal("json-service"))
+		Expect(time.Duration(cfg.Exporter.Timeout)).Should(Equal(time.Minute))
+	})
+
+	It("Init succeed", func() {
+		savedProvider, savedPropagator, savedDefault := otel.GetTracerProvider(), otel.GetTextMapPropagator(), getDefaultPropagator()
+		defer func() {
+			otel.SetTracerProvider(savedProvider)
+			otel.SetTextMapPropagator(savedPropagator)
+			setDefaultPropagator(savedDefault)
+		}()
+
+		shutdown, err := Init(context.Background(), Config{
+			ServiceName: "init-service",
+			Exporter:    ExporterConfig{Type: ExporterNone},
+			Sampler:     SamplerConfig{Type: "always_on"},
+			Propagators: []string{"tracecontext"},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer func() { Expect(shutdown(context.Background())).Should(Succeed()) }()
+
+		Expect(otel.GetTextMapPropagator().Fields()).Should(ConsistOf("traceparent", "tracestate"))
+		header := propagation.MapCarrier{}
+		ctx, span := NewTracer(trace.SpanKindClient).Start(context.Background(), "init-span", header)
+		defer span.End()
+		Expect(trace.SpanFromContext(ctx).SpanContext().IsSampled()).Should(BeTrue())
+		Expect(header).Should(HaveKey("traceparent"))
+		Expect(header).ShouldNot(HaveKey(serviceHeader))
+	})
+
+	It("Init fail", func() {
+		_, err := Init(context.Background(), Config{ServiceName: "init-service", Exporter: ExporterConfig{Type: "unknown"}})
+		Expect(err).Should(HaveOccurred())
+		_, err = Init(context.Background(), Config{ServiceName: "init-service", Propagators: []string{"unknown"}})
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("ConfigFromEnv", func() {
+	var restore []func()
+	setEnv := func(env map[string]string) {
+		for k, v := range env {
+			k := k
+			saved, ok := os.LookupEnv(k)
+			restore = append(restore, func() {
+				if ok {
+					_ = os.Setenv(k, saved)
+				} else {
+					_ = os.Unsetenv(k)
+				}
+			})
+			Expect(os.Setenv(k, v)).Should(Succeed())
+		}
+	}
+	AfterEach(func() {
+		for _, f := range restore {
+			f()
+		}
+		restore = nil
+	})
+
+	It("succeed", func() {
+		setEnv(map[string]string{
+			"OTEL_SERVICE_NAME":                      "env-service",
+			"OTEL_RESOURCE_ATTRIBUTES":               "service.name=ignored,team=infra,region=cn%20east,bad",
+			"OTEL_TRACES_EXPORTER":                   "otlp",
+			"OTEL_EXPORTER_OTLP_PROTOCOL":            "http/json",
+			"OTEL_PROPAGATORS":                       "tracecontext,baggage",
+			"OTEL_BSP_SCHEDULE_DELAY":                "200",
+			"OTEL_BSP_MAX_QUEUE_SIZE":                "4096",
+			"OTEL_BSP_MAX_EXPORT_BATCH_SIZE":         "nan",
+			"OTEL_ATTRIBUTE_COUNT_LIMIT":             "64",
+			"OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT":        "32",
+			"OTEL_SPAN_EVENT_COUNT_LIMIT":            "16",
+			"OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT": "1024",
+		})
+
+		cfg := ConfigFromEnv()
+		Expect(cfg.ServiceName).Should(Equal("env-service"))
+		Expect(cfg.ResourceAttributes).Should(Equal(map[string]string{
+			"service.name": "ignored",
+			"team":         "infra",
+			"region":       "cn east",
+		}))
+		Expect(configResourceAttributes(cfg.ResourceAttributes)).Should(HaveLen(2))
+		Expect(cfg.Exporter.Type).Should(Equal(ExporterOTLPHTTP))
+		Expect(cfg.Exporter.Encoding).Should(Equal("json"))
+		Expect(cfg.Propagators).Should(Equal([]string{"tracecontext", "baggage"}))
+		Expect(cfg.Batch).Should(Equal(BatchConfig{
+			ScheduleDelay: Duration(200 * time.Millisecond),
+			MaxQueueSize:  4096,
+		}))
+		Expect(cfg.SpanLimits).Should(Equal(SpanLimitsConfig{
+			AttributeValueLength:   1024,
+			AttributeCount:         32,
+			EventCount:             16,
+			AttributePerEventCount: 64,
+			AttributePerLinkCount:  64,
+		}))
+		Expect(cfg.Disabled).Should(BeFalse())
+	})
+
+	It("config wins", func() {
+		setEnv(map[string]string{
+			"OTEL_SERVICE_NAME":        "env-service",
+			"OTEL_RESOURCE_ATTRIBUTES": "team=infra",
+			"OTEL_TRACES_EXPORTER":     "jaeger",
+			"OTEL_BSP_MAX_QUEUE_SIZE":  "4096",
+		})
+
+		cfg := Config{
+			ServiceName:        "code-service",
+			ResourceAttributes: map[string]string{"team": "app"},
+			Batch:              BatchConfig{MaxQueueSize: 100},
+		}
+		cfg.applyEnv()
+		Expect(cfg.ServiceName).Should(Equal("code-service"))
+		Expect(cfg.ResourceAttributes).Should(HaveKeyWithValue("team", "app"))
+		Expect(cfg.Exporter.Type).Should(Equal(ExporterJaegerCollector))
+		Expect(cfg.Batch.MaxQueueSize).Should(Equal(100))
+	})
+
+	It("exporter types", func() {
+		setEnv(map[string]string{"OTEL_EXPORTER_JAEGER_PROTOCOL": "udp/thrift.compact"})
+		Expect(exporterTypeFromEnv("jaeger")).Should(Equal(ExporterJaegerAgent))
+		Expect(exporterTypeFromEnv("otlp")).Should(Equal(ExporterOTLPGRPC))
+		Expect(exporterTypeFromEnv("none,otlp")).Should(Equal(ExporterNone))
+		Expect(exporterTypeFromEnv("")).Should(BeEmpty())
+	})
+
+	It("Init reads the environment", func() {
+		setEnv(map[string]string{
+			"OTEL_SERVICE_NAME":        "env-service",
+			"OTEL_RESOURCE_ATTRIBUTES": "team=infra,region=eu",
+		})
+		savedProvider, savedPropagator, savedDefault := otel.GetTracerProvider(), otel.GetTextMapPropagator(), getDefaultPropagator()
+		defer func() {
+			otel.SetTracerProvider(savedProvider)
+			otel.SetTextMapPropagator(savedPropagator)
+			setDefaultPropagator(savedDefault)
+		}()
+
+		attrs := map[string]string{"team": "app"}
+		shutdown, err := Init(context.Background(), Config{
+			ResourceAttributes: attrs,
+			Exporter:           ExporterConfig{Type: ExporterNone},
+			Propagators:        []string{"tracecontext"},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer func() { Expect(shutdown(context.Background())).Should(Succeed()) }()
+		Expect(attrs).Should(Equal(map[string]string{"team": "app"}))
+
+		_, span := otel.Tracer("test").Start(context.Background(), "env-span")
+		span.End()
+		res := span.(tracesdk.ReadOnlySpan).Resource()
+		name, _ := res.Set().Value(semconv.ServiceNameKey)
+		team, _ := res.Set().Value("team")
+		region, _ := res.Set().Value("region")
+		Expect(name.AsString()).Should(Equal("env-service"))
+		Expect(team.AsString()).Should(Equal("app"))
+		Expect(region.AsString()).Should(Equal("eu"))
+
+		current := otel.GetTracerProvider()
+		setEnv(map[string]string{"OTEL_SDK_DISABLED": "true"})
+		disabled, err := Init(context.Background(), Config{ServiceName: "code-service"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(disabled(context.Background())).Should(Succeed())
+		Expect(otel.GetTracerProvider()).Should(BeIdenticalTo(current))
+	})
+
+	It("disabled", func() {
+		setEnv(map[string]string{"OTEL_SDK_DISABLED": "TRUE"})
+		saved := otel.GetTracerProvider()
+
+		cfg := ConfigFromEnv()
+		Expect(cfg.Disabled).Should(BeTrue())
+		shutdown, err := Init(context.Background(), cfg)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(shutdown(context.Background())).Should(Succeed())
+		Expect(otel.GetTracerProvider()).Should(BeIdenticalTo(saved))
+	})
+})
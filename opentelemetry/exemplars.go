@@ -0,0 +1,145 @@
+package opentelemetry
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxSeriesExemplars is the number of the latest exemplars kept per http.server.duration series,
+// the latest one of each bucket is exposed
+const maxSeriesExemplars = 16
+
+// exemplar label keys, see https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
+const (
+	exemplarTraceIDKey = "trace_id"
+	exemplarSpanIDKey  = "span_id"
+)
+
+// exemplarStores are the exemplar stores of the meter providers of MeterProviderWithPrometheus
+var exemplarStores sync.Map // metric.MeterProvider -> *exemplarStore
+
+// exemplarStoreOf returns the exemplar store of mp, nil if it doesn't export to Prometheus
+func exemplarStoreOf(mp metric.MeterProvider) *exemplarStore {
+	if s, ok := exemplarStores.Load(mp); ok {
+		return s.(*exemplarStore)
+	}
+	return nil
+}
+
+// exemplarSeries identifies a http.server.duration series by its labels
+type exemplarSeries struct {
+	method, statusClass, route string
+}
+
+// exemplarStore keeps the latest sampled traces of the http.server.duration series, the otel SDK
+// doesn't record exemplars so they are attached to the Prometheus histograms on collection
+type exemplarStore struct {
+	mu        sync.Mutex
+	exemplars map[exemplarSeries][]prometheus.Exemplar
+}
+
+func newExemplarStore() *exemplarStore {
+	return &exemplarStore{exemplars: make(map[exemplarSeries][]prometheus.Exemplar)}
+}
+
+// record keeps the duration of a request served in the sampled span sc
+func (s *exemplarStore) record(sc trace.SpanContext, series exemplarSeries, ms float64) {
+	if !sc.IsSampled() {
+		return
+	}
+	e := prometheus.Exemplar{
+		Value: ms,
+		Labels: prometheus.Labels{
+			exemplarTraceIDKey: sc.TraceID().String(),
+			exemplarSpanIDKey:  sc.SpanID().String(),
+		},
+		Timestamp: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exemplars := append(s.exemplars[series], e)
+	if len(exemplars) > maxSeriesExemplars {
+		exemplars = append(exemplars[:0:0], exemplars[len(exemplars)-maxSeriesExemplars:]...)
+	}
+	s.exemplars[series] = exemplars
+}
+
+// attach returns m with the exemplars of its series if it is a http.server.duration histogram
+func (s *exemplarStore) attach(m prometheus.Metric) prometheus.Metric {
+	// named http_server_duration_milliseconds, or http_server_duration without the unit suffixes
+	if !strings.HasPrefix(m.Desc().String(), `Desc{fqName: "http_server_duration`) {
+		return m
+	}
+	var pb dto.Metric
+	if err := m.Write(&pb); err != nil || pb.Histogram == nil {
+		return m
+	}
+	var series exemplarSeries
+	for _, lp := range pb.GetLabel() {
+		switch lp.GetName() {
+		case "http_method":
+			series.method = lp.GetValue()
+		case "http_status_class":
+			series.statusClass = lp.GetValue()
+		case "http_route":
+			series.route = lp.GetValue()
+		}
+	}
+
+	s.mu.Lock()
+	// the later exemplars replace the earlier ones of the same bucket
+	exemplars := append([]prometheus.Exemplar(nil), s.exemplars[series]...)
+	s.mu.Unlock()
+	if len(exemplars) == 0 {
+		return m
+	}
+	em, err := prometheus.NewMetricWithExemplars(m, exemplars...)
+	if err != nil {
+		otel.Handle(err)
+		return m
+	}
+	return em
+}
+
+// exemplarRegisterer registers the collector of the otel Prometheus exporter with the exemplars of store
+type exemplarRegisterer struct {
+	prometheus.Registerer
+	store *exemplarStore
+}
+
+func (r exemplarRegisterer) Register(c prometheus.Collector) error {
+	return r.Registerer.Register(exemplarCollector{Collector: c, store: r.store})
+}
+
+func (r exemplarRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// exemplarCollector attaches the exemplars of store to the metrics of the wrapped collector
+type exemplarCollector struct {
+	prometheus.Collector
+	store *exemplarStore
+}
+
+func (c exemplarCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		defer close(metrics)
+		c.Collector.Collect(metrics)
+	}()
+	for m := range metrics {
+		ch <- c.store.attach(m)
+	}
+}
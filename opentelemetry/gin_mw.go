@@ -6,7 +6,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/propagation"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -14,7 +13,18 @@ import (
 // The service parameter should describe the name of the (virtual)
 // server handling the request.
 func Tracing(service string, opts ...Option) gin.HandlerFunc {
-	tracer := NewTracer(trace.SpanKindServer, opts...)
+	handlerOpts := make([]HandlerOption, 0, len(opts))
+	for _, o := range opts {
+		handlerOpts = append(handlerOpts, o)
+	}
+	return TracingWithOptions(service, handlerOpts...)
+}
+
+// TracingWithOptions is Tracing with the handler options, like WithMetrics.
+func TracingWithOptions(service string, opts ...HandlerOption) gin.HandlerFunc {
+	op := newHandlerOptions(opts)
+	tracer := NewTracer(trace.SpanKindServer, op.tracer...)
+	metrics := newHTTPServerMetrics(op)
 
 	return func(c *gin.Context) {
 		savedCtx := c.Request.Context()
@@ -23,36 +33,33 @@ func Tracing(service string, opts ...Option) gin.HandlerFunc {
 			c.Request = c.Request.WithContext(savedCtx)
 		}()
 
-		opts := []trace.SpanStartOption{
-			trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", c.Request)...),
-			trace.WithAttributes(semconv.EndUserAttributesFromHTTPRequest(c.Request)...),
-			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(service, c.FullPath(), c.Request)...),
-		}
-
 		spanName := c.FullPath()
 		if spanName == "" {
 			spanName = fmt.Sprintf("HTTP %s route not found", c.Request.Method)
 		}
 
-		ctx, span := tracer.Start(savedCtx, spanName, propagation.HeaderCarrier(c.Request.Header), opts...)
+		ctx, span := tracer.Start(savedCtx, spanName, propagation.HeaderCarrier(c.Request.Header),
+			httpServerStartOptions(service, c.FullPath(), c.Request)...)
 
 		// pass the span through the request context
 		c.Request = c.Request.WithContext(ctx)
+		served := metrics.start(ctx, c.Request.Method)
 
 		// serve the request to the next middleware
 		c.Next()
 
-		status := c.Writer.Status()
-		attrs := semconv.HTTPAttributesFromHTTPStatusCode(status)
-		spanStatus, spanMessage := semconv.SpanStatusFromHTTPStatusCode(status)
-		span.SetAttributes(attrs...)
-		span.SetStatus(spanStatus, spanMessage)
 		var err error
 		if len(c.Errors) > 0 {
 			//span.SetAttributes(attribute.String("gin.errors", c.Errors.String()))
 			err = fmt.Errorf("gin.errors:%s", c.Errors.String())
 		}
-		tracer.End(ctx, span, "", err)
+		endHTTPServerSpan(ctx, tracer, span, c.Writer.Status(), err)
+		// the size is -1 until the body is written
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		served(ctx, c.FullPath(), c.Writer.Status(), c.Request.ContentLength, int64(size))
 	}
 }
 
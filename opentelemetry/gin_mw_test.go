@@ -0,0 +1,73 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("Tracing", func() {
+	var recorder *oteltest.Recorder
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("records the server span", func() {
+		engine := gin.New()
+		engine.Use(Tracing("test"))
+		engine.GET("/users/:id", func(c *gin.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		engine.GET("/fail", func(c *gin.Context) {
+			_ = c.Error(errors.New("boom"))
+			c.String(http.StatusBadRequest, "fail")
+		})
+
+		// the caller span is propagated through the request headers
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		_, caller := NewTracer(trace.SpanKindClient).Start(context.Background(), "caller", propagation.HeaderCarrier(req.Header))
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		caller.End()
+
+		Expect(recorder).Should(oteltest.HaveSpan("/users/:id",
+			oteltest.HaveKind(trace.SpanKindServer),
+			oteltest.HaveAttribute("http.route", "/users/:id"),
+			oteltest.HaveAttribute("http.method", http.MethodGet),
+			oteltest.HaveAttribute("http.status_code", http.StatusOK),
+			oteltest.BeChildOf(caller),
+		))
+
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+		Expect(recorder).Should(oteltest.HaveSpan("/fail",
+			oteltest.HaveAttribute("http.status_code", http.StatusBadRequest),
+			oteltest.HaveStatus(codes.Error),
+		))
+	})
+
+	It("accepts a slice of tracer options", func() {
+		opts := []Option{WithPropagator(propagation.TraceContext{})}
+		engine := gin.New()
+		engine.Use(Tracing("test", opts...))
+		engine.GET("/ping", func(c *gin.Context) {
+			c.String(http.StatusOK, "pong")
+		})
+
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+		Expect(recorder).Should(oteltest.HaveSpan("/ping", oteltest.HaveKind(trace.SpanKindServer)))
+	})
+})
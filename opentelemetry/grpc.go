@@ -0,0 +1,147 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// message event attributes of the gRPC spans
+const (
+	messageEvent               = "message"
+	messageTypeKey             = attribute.Key("message.type")
+	messageIDKey               = attribute.Key("message.id")
+	messageUncompressedSizeKey = attribute.Key("message.uncompressed_size")
+
+	messageTypeSent     = "SENT"
+	messageTypeReceived = "RECEIVED"
+)
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier
+type metadataCarrier struct {
+	md *metadata.MD
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier{}
+
+// Get returns the first value of key.
+func (c metadataCarrier) Get(key string) string {
+	values := c.md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set replaces the values of key.
+func (c metadataCarrier) Set(key, value string) {
+	c.md.Set(key, value)
+}
+
+// Keys lists the keys of the metadata.
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.md))
+	for k := range *c.md {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// rpcSpanInfo returns the span name and the rpc attributes of a full method like /package.Service/Method
+func rpcSpanInfo(fullMethod string, peerAddr net.Addr) (string, []attribute.KeyValue) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	attrs := []attribute.KeyValue{semconv.RPCSystemKey.String("grpc")}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		if service := name[:i]; service != "" {
+			attrs = append(attrs, semconv.RPCServiceKey.String(service))
+		}
+		if method := name[i+1:]; method != "" {
+			attrs = append(attrs, semconv.RPCMethodKey.String(method))
+		}
+	}
+	return name, append(attrs, peerAttributesFromAddr(peerAddr)...)
+}
+
+func peerAttributesFromAddr(addr net.Addr) []attribute.KeyValue {
+	if addr == nil {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	var attrs []attribute.KeyValue
+	if ip := net.ParseIP(host); ip != nil {
+		attrs = append(attrs, semconv.NetPeerIPKey.String(host))
+	} else if host != "" {
+		attrs = append(attrs, semconv.NetPeerNameKey.String(host))
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.NetPeerPortKey.Int(p))
+	}
+	return attrs
+}
+
+func peerAddr(ctx context.Context) net.Addr {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr
+	}
+	return nil
+}
+
+// addMessageEvent records a sent or received message, id counts from 1 per direction
+func addMessageEvent(span trace.Span, messageType string, id int, m interface{}) {
+	attrs := []attribute.KeyValue{messageTypeKey.String(messageType), messageIDKey.Int(id)}
+	if p, ok := m.(proto.Message); ok {
+		attrs = append(attrs, messageUncompressedSizeKey.Int(proto.Size(p)))
+	}
+	span.AddEvent(messageEvent, trace.WithAttributes(attrs...))
+}
+
+// grpcServerFault reports the status codes caused by the server,
+// the others are the client's fault and leave the server span status unset
+func grpcServerFault(code grpccodes.Code) bool {
+	switch code {
+	case grpccodes.Unknown, grpccodes.DeadlineExceeded, grpccodes.Unimplemented,
+		grpccodes.Internal, grpccodes.Unavailable, grpccodes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// endRPCSpan sets the gRPC status code and ends the span, the error status is set for any
+// failed client call but only for the server faults of a server call
+func endRPCSpan(ctx context.Context, tracer *Tracer, span trace.Span, m interface{}, err error, kv ...attribute.KeyValue) {
+	s := grpcStatus(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(s.Code())))
+	if err != nil && tracer.kind == trace.SpanKindServer && !grpcServerFault(s.Code()) {
+		span.RecordError(err)
+		tracer.end(ctx, span, m, nil, false, kv...)
+		return
+	}
+	tracer.End(ctx, span, m, err, kv...)
+}
+
+// grpcStatus converts err to a status, context errors become Canceled and DeadlineExceeded
+func grpcStatus(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+	return status.Convert(err)
+}
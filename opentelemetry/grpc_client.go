@@ -0,0 +1,191 @@
+package opentelemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor tracing outgoing unary calls,
+// the span is injected into the outgoing metadata.
+//
+//	grpc.Dial(target, grpc.WithChainUnaryInterceptor(opentelemetry.UnaryClientInterceptor()))
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ctx, span := startClientRPCSpan(ctx, tracer, method, cc.Target())
+		addMessageEvent(span, messageTypeSent, 1, req)
+
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		var m interface{}
+		if err == nil {
+			addMessageEvent(span, messageTypeReceived, 1, reply)
+			m = reply
+		}
+		endRPCSpan(ctx, tracer, span, m, err, attribute.Key("send_msg.size").Int(messageSize(req)))
+
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor tracing outgoing streams.
+// The span ends when the stream does: on io.EOF or an error from RecvMsg, the response of
+// a client streaming call, or the cancellation of the stream context.
+//
+//	grpc.Dial(target, grpc.WithChainStreamInterceptor(opentelemetry.StreamClientInterceptor()))
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := startClientRPCSpan(ctx, tracer, method, cc.Target())
+
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			endRPCSpan(ctx, tracer, span, nil, err)
+			return cs, err
+		}
+
+		stream := &clientStream{ClientStream: cs, desc: desc, span: span, done: make(chan struct{})}
+		stream.end = func(err error) {
+			endRPCSpan(ctx, tracer, span, nil, err,
+				attribute.Key("send_msg.size").Int(stream.sentSize),
+				attribute.Key("recv_msg.size").Int(stream.receivedSize),
+			)
+		}
+		go func() {
+			select {
+			case <-ctx.Done():
+				stream.finish(ctx.Err())
+			case <-stream.done:
+			}
+		}()
+		return stream, nil
+	}
+}
+
+func startClientRPCSpan(ctx context.Context, tracer *Tracer, fullMethod, target string) (context.Context, trace.Span) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	name, attrs := rpcSpanInfo(fullMethod, nil)
+
+	ctx, span := tracer.Start(ctx, name, metadataCarrier{md: &md},
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(targetAttributes(target)...),
+	)
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// targetAttributes are the peer attributes of a dial target like dns:///host:port
+func targetAttributes(target string) []attribute.KeyValue {
+	if i := strings.Index(target, "://"); i >= 0 {
+		target = target[i+3:]
+		target = target[strings.Index(target, "/")+1:]
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		if target == "" {
+			return nil
+		}
+		return []attribute.KeyValue{semconv.NetPeerNameKey.String(target)}
+	}
+	attrs := []attribute.KeyValue{semconv.NetPeerNameKey.String(host)}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.NetPeerPortKey.Int(p))
+	}
+	return attrs
+}
+
+func messageSize(m interface{}) int {
+	if p, ok := m.(proto.Message); ok {
+		return proto.Size(p)
+	}
+	return 0
+}
+
+// clientStream records the messages and ends the span once with the stream
+type clientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+	span trace.Span
+	end  func(err error)
+	done chan struct{}
+	once sync.Once
+
+	mu                     sync.Mutex
+	sent, received         int
+	sentSize, receivedSize int
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.mu.Lock()
+		s.sent++
+		s.sentSize += messageSize(m)
+		id := s.sent
+		s.mu.Unlock()
+		addMessageEvent(s.span, messageTypeSent, id, m)
+	} else if err != io.EOF {
+		// io.EOF means the stream is aborted, RecvMsg returns the status
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	default:
+		s.mu.Lock()
+		s.received++
+		s.receivedSize += messageSize(m)
+		id := s.received
+		s.mu.Unlock()
+		addMessageEvent(s.span, messageTypeReceived, id, m)
+		if !s.desc.ServerStreams {
+			// the single response of a client streaming call ends it
+			s.finish(nil)
+		}
+	}
+	return err
+}
+
+func (s *clientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.finish(err)
+	}
+	return md, err
+}
+
+func (s *clientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.end(err)
+	})
+}
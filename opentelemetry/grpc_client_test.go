@@ -0,0 +1,119 @@
+package opentelemetry
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("gRPC client interceptors", func() {
+	var (
+		recorder *oteltest.Recorder
+		client   healthpb.HealthClient
+		stop     func()
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		var conn *grpc.ClientConn
+		conn, stop = newBufconnHealthServer(
+			[]grpc.ServerOption{
+				grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+				grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+			},
+			grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+		)
+		client = healthpb.NewHealthClient(conn)
+	})
+
+	AfterEach(func() {
+		stop()
+		recorder.Uninstall()
+	})
+
+	It("unary succeed", func() {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user", "1")
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		md, _ := metadata.FromOutgoingContext(ctx)
+		Expect(md.Get("traceparent")).Should(BeEmpty())
+
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Check",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveAttribute("rpc.service", "grpc.health.v1.Health"),
+			oteltest.HaveAttribute("rpc.grpc.status_code", 0),
+			oteltest.HaveAttribute("net.peer.name", "bufnet"),
+			oteltest.HaveAttribute("send_msg.size", 0),
+			oteltest.HaveAttribute("recv_msg.size", 2),
+			oteltest.HaveStatus(codes.Ok),
+		))
+		// the server span ends first
+		spans := recorder.Spans()
+		Expect(spans).Should(HaveLen(2))
+		Expect(spans[0]).Should(oteltest.HaveKind(trace.SpanKindServer))
+		Expect(spans[0]).Should(oteltest.BeChildOf(spans[1]))
+	})
+
+	It("unary error", func() {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+		Expect(status.Code(err)).Should(Equal(grpccodes.NotFound))
+
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Check",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveAttribute("rpc.grpc.status_code", int(grpccodes.NotFound)),
+			oteltest.HaveStatus(codes.Error),
+		))
+	})
+
+	It("stream cancel", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		cancel()
+
+		Eventually(recorder.Spans).Should(HaveLen(2))
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Watch",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveAttribute("rpc.grpc.status_code", int(grpccodes.Canceled)),
+			oteltest.HaveAttribute("recv_msg.size", 2),
+			oteltest.HaveStatus(codes.Error),
+		))
+
+		// the next receive reports the cancellation but the span is ended once
+		_, err = stream.Recv()
+		Expect(status.Code(err)).Should(Equal(grpccodes.Canceled))
+		Expect(recorder.Spans()).Should(HaveLen(2))
+	})
+
+	It("stream error", func() {
+		stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+		Expect(err).ShouldNot(HaveOccurred())
+		resp, err := stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Status).Should(Equal(healthpb.HealthCheckResponse_SERVICE_UNKNOWN))
+
+		stop()
+		_, err = stream.Recv()
+		Expect(err).Should(HaveOccurred())
+		Eventually(recorder.Spans).Should(ContainElement(
+			oteltest.HaveKind(trace.SpanKindClient),
+		))
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Watch",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveStatus(codes.Error),
+		))
+	})
+})
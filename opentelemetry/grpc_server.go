@@ -0,0 +1,86 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor tracing incoming unary calls,
+// the remote span is extracted from the incoming metadata.
+//
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(opentelemetry.UnaryServerInterceptor()))
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	tracer := NewTracer(trace.SpanKindServer, opts...)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startServerRPCSpan(ctx, tracer, info.FullMethod)
+		addMessageEvent(span, messageTypeReceived, 1, req)
+
+		resp, err := handler(ctx, req)
+		if err == nil {
+			addMessageEvent(span, messageTypeSent, 1, resp)
+		}
+		endRPCSpan(ctx, tracer, span, resp, err)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor tracing incoming streams,
+// every message sent and received is recorded as a span event.
+//
+//	grpc.NewServer(grpc.ChainStreamInterceptor(opentelemetry.StreamServerInterceptor()))
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	tracer := NewTracer(trace.SpanKindServer, opts...)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startServerRPCSpan(ss.Context(), tracer, info.FullMethod)
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx, span: span})
+		endRPCSpan(ctx, tracer, span, nil, err)
+
+		return err
+	}
+}
+
+func startServerRPCSpan(ctx context.Context, tracer *Tracer, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	name, attrs := rpcSpanInfo(fullMethod, peerAddr(ctx))
+
+	return tracer.Start(ctx, name, metadataCarrier{md: &md}, trace.WithAttributes(attrs...))
+}
+
+// serverStream passes the span context to the handler and records the messages
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	span trace.Span
+
+	sent, received int
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+		addMessageEvent(s.span, messageTypeSent, s.sent, m)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+		addMessageEvent(s.span, messageTypeReceived, s.received, m)
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package opentelemetry
+
+import (
+	"context"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+// newBufconnHealthServer serves the health service in process, the service "broken" fails with Internal
+func newBufconnHealthServer(serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (*grpc.ClientConn, func()) {
+	lis := bufconn.Listen(1 << 20)
+	breaker := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if r, ok := req.(*healthpb.HealthCheckRequest); ok && r.Service == "broken" {
+			return nil, status.Error(grpccodes.Internal, "broken")
+		}
+		return handler(ctx, req)
+	}
+	server := grpc.NewServer(append(serverOpts, grpc.ChainUnaryInterceptor(breaker))...)
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, hs)
+	go func() { _ = server.Serve(lis) }()
+
+	conn, err := grpc.Dial("bufnet", append(dialOpts,
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)...)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return conn, func() {
+		_ = conn.Close()
+		server.Stop()
+	}
+}
+
+var _ = Describe("gRPC server interceptors", func() {
+	var (
+		recorder *oteltest.Recorder
+		client   healthpb.HealthClient
+		stop     func()
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		var conn *grpc.ClientConn
+		conn, stop = newBufconnHealthServer([]grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+		})
+		client = healthpb.NewHealthClient(conn)
+	})
+
+	AfterEach(func() {
+		stop()
+		recorder.Uninstall()
+	})
+
+	It("unary succeed", func() {
+		// the caller span is propagated through the outgoing metadata
+		ctx, caller := NewTracer(trace.SpanKindInternal).Start(context.Background(), "caller", nil)
+		md := metadata.MD{}
+		propagation.TraceContext{}.Inject(ctx, metadataCarrier{md: &md})
+		_, err := client.Check(metadata.NewOutgoingContext(ctx, md), &healthpb.HealthCheckRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		caller.End()
+
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Check",
+			oteltest.HaveKind(trace.SpanKindServer),
+			oteltest.HaveAttribute("rpc.system", "grpc"),
+			oteltest.HaveAttribute("rpc.service", "grpc.health.v1.Health"),
+			oteltest.HaveAttribute("rpc.method", "Check"),
+			oteltest.HaveAttribute("rpc.grpc.status_code", 0),
+			oteltest.HaveAttribute("send_msg.size", 2),
+			oteltest.HaveStatus(codes.Ok),
+			oteltest.BeChildOf(caller),
+		))
+		span, _ := recorder.Span("grpc.health.v1.Health/Check")
+		Expect(span.Events).Should(HaveLen(2))
+		Expect(span.Events[0].Name).Should(Equal("message"))
+	})
+
+	It("unary error", func() {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+		Expect(status.Code(err)).Should(Equal(grpccodes.NotFound))
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Check",
+			oteltest.HaveAttribute("rpc.grpc.status_code", int(grpccodes.NotFound)),
+			oteltest.HaveStatus(codes.Unset),
+		))
+
+		recorder.Reset()
+		_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "broken"})
+		Expect(status.Code(err)).Should(Equal(grpccodes.Internal))
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Check",
+			oteltest.HaveAttribute("rpc.grpc.status_code", int(grpccodes.Internal)),
+			oteltest.HaveStatus(codes.Error),
+		))
+	})
+
+	It("stream succeed", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		resp, err := stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Status).Should(Equal(healthpb.HealthCheckResponse_SERVING))
+		cancel()
+
+		Eventually(recorder.Spans).Should(HaveLen(1))
+		Expect(recorder).Should(oteltest.HaveSpan("grpc.health.v1.Health/Watch",
+			oteltest.HaveKind(trace.SpanKindServer),
+			oteltest.HaveAttribute("rpc.method", "Watch"),
+			oteltest.HaveAttribute("rpc.grpc.status_code", int(grpccodes.Canceled)),
+		))
+		span, _ := recorder.Span("grpc.health.v1.Health/Watch")
+		var types []string
+		for _, e := range span.Events {
+			for _, kv := range e.Attributes {
+				if kv.Key == messageTypeKey {
+					types = append(types, kv.Value.AsString())
+				}
+			}
+		}
+		Expect(types).Should(Equal([]string{messageTypeReceived, messageTypeSent}))
+	})
+})
@@ -0,0 +1,154 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RED metrics of the http server middleware
+const (
+	httpServerDuration       = "http.server.duration"
+	httpServerActiveRequests = "http.server.active_requests"
+	httpServerRequestSize    = "http.server.request.size"
+	httpServerResponseSize   = "http.server.response.size"
+)
+
+// statusClassKey is the class of the response status code, like 2xx
+const statusClassKey = attribute.Key("http.status_class")
+
+// WithMetrics with TracingWithOptions and TracingHandler also recording the RED metrics of the requests with mp,
+// the global meter provider if nil: http.server.duration, http.server.active_requests,
+// http.server.request.size and http.server.response.size. They are labelled by method, route
+// template and status class, never by raw path, the unknown methods are labelled _OTHER.
+//
+// If mp is a MeterProviderWithPrometheus provider, the http.server.duration buckets also carry the
+// trace_id and span_id of the latest sampled requests as exemplars.
+func WithMetrics(mp metric.MeterProvider) HandlerOption {
+	return handlerOptionFunc(func(opts *handlerOptions) {
+		opts.metrics = true
+		opts.meterProvider = mp
+	})
+}
+
+// httpServerMetrics records the RED metrics of a middleware
+type httpServerMetrics struct {
+	// meterProvider is nil for the global one, which may be set after the middleware is created
+	meterProvider metric.MeterProvider
+
+	duration     instrument.Float64Histogram
+	active       instrument.Int64UpDownCounter
+	requestSize  instrument.Int64Histogram
+	responseSize instrument.Int64Histogram
+}
+
+// newHTTPServerMetrics returns nil without WithMetrics, the creation errors are sent to otel.Handle
+func newHTTPServerMetrics(op handlerOptions) *httpServerMetrics {
+	if !op.metrics {
+		return nil
+	}
+	mp := op.meterProvider
+	if mp == nil {
+		mp = global.MeterProvider()
+	}
+	meter := mp.Meter("weecloudy-tracer", metric.WithInstrumentationVersion(SemVersion()))
+
+	var err error
+	m := httpServerMetrics{meterProvider: op.meterProvider}
+	if m.duration, err = meter.Float64Histogram(httpServerDuration,
+		instrument.WithUnit(string(unit.Milliseconds)),
+		instrument.WithDescription("Duration of the inbound HTTP requests")); err != nil {
+		otel.Handle(err)
+	}
+	if m.active, err = meter.Int64UpDownCounter(httpServerActiveRequests,
+		instrument.WithUnit("{request}"),
+		instrument.WithDescription("Number of the inbound HTTP requests in flight")); err != nil {
+		otel.Handle(err)
+	}
+	if m.requestSize, err = meter.Int64Histogram(httpServerRequestSize,
+		instrument.WithUnit(string(unit.Bytes)),
+		instrument.WithDescription("Size of the inbound HTTP request bodies")); err != nil {
+		otel.Handle(err)
+	}
+	if m.responseSize, err = meter.Int64Histogram(httpServerResponseSize,
+		instrument.WithUnit(string(unit.Bytes)),
+		instrument.WithDescription("Size of the HTTP response bodies")); err != nil {
+		otel.Handle(err)
+	}
+	return &m
+}
+
+// start counts an active request and returns the function recording it once served
+func (m *httpServerMetrics) start(ctx context.Context, method string) func(ctx context.Context, route string, status int, requestSize, responseSize int64) {
+	if m == nil {
+		return func(context.Context, string, int, int64, int64) {}
+	}
+	begin := time.Now()
+	methodAttr := semconv.HTTPMethodKey.String(metricMethod(method))
+	if m.active != nil {
+		m.active.Add(ctx, 1, methodAttr)
+	}
+
+	return func(ctx context.Context, route string, status int, requestSize, responseSize int64) {
+		if m.active != nil {
+			m.active.Add(ctx, -1, methodAttr)
+		}
+
+		attrs := []attribute.KeyValue{methodAttr, statusClassKey.String(statusClass(status))}
+		if route != "" {
+			attrs = append(attrs, semconv.HTTPRouteKey.String(route))
+		}
+		if m.duration != nil {
+			ms := float64(time.Since(begin)) / float64(time.Millisecond)
+			m.duration.Record(ctx, ms, attrs...)
+			if store := m.exemplars(); store != nil {
+				store.record(trace.SpanContextFromContext(ctx),
+					exemplarSeries{method: methodAttr.Value.AsString(), statusClass: statusClass(status), route: route}, ms)
+			}
+		}
+		if m.requestSize != nil && requestSize >= 0 {
+			m.requestSize.Record(ctx, requestSize, attrs...)
+		}
+		if m.responseSize != nil && responseSize >= 0 {
+			m.responseSize.Record(ctx, responseSize, attrs...)
+		}
+	}
+}
+
+// exemplars is the exemplar store of the meter provider, nil if it doesn't export to Prometheus
+func (m *httpServerMetrics) exemplars() *exemplarStore {
+	mp := m.meterProvider
+	if mp == nil {
+		mp = global.MeterProvider()
+	}
+	return exemplarStoreOf(mp)
+}
+
+// metricMethod bounds the methods sent by the clients to the known ones
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "_OTHER"
+	}
+}
+
+// statusClass is 1xx to 5xx
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,137 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+// collectMetrics returns the metrics collected by reader by name
+func collectMetrics(reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	Expect(reader.Collect(context.Background(), &rm)).Should(Succeed())
+
+	metrics := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
+
+var _ = Describe("WithMetrics", func() {
+	var (
+		recorder *oteltest.Recorder
+		reader   sdkmetric.Reader
+		mp       *sdkmetric.MeterProvider
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		reader = sdkmetric.NewManualReader()
+		mp = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	})
+
+	AfterEach(func() {
+		_ = mp.Shutdown(context.Background())
+		recorder.Uninstall()
+	})
+
+	It("records the gin requests", func() {
+		engine := gin.New()
+		engine.Use(TracingWithOptions("test", WithMetrics(mp)))
+		engine.POST("/users/:id", func(c *gin.Context) {
+			c.String(http.StatusCreated, "created")
+		})
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader("body")))
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/2", strings.NewReader("body")))
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		metrics := collectMetrics(reader)
+		Expect(metrics).Should(HaveKey("http.server.duration"))
+		Expect(metrics["http.server.duration"].Unit).Should(BeEquivalentTo("ms"))
+		duration := metrics["http.server.duration"].Data.(metricdata.Histogram).DataPoints
+		Expect(duration).Should(HaveLen(2))
+		for _, dp := range duration {
+			route, _ := dp.Attributes.Value("http.route")
+			class, _ := dp.Attributes.Value("http.status_class")
+			switch class.AsString() {
+			case "2xx":
+				Expect(route.AsString()).Should(Equal("/users/:id"))
+				Expect(dp.Count).Should(BeEquivalentTo(2))
+			case "4xx":
+				// unmatched requests are not labelled with their raw path
+				Expect(dp.Attributes.HasValue("http.route")).Should(BeFalse())
+			default:
+				Fail("unexpected status class " + class.AsString())
+			}
+		}
+
+		active := metrics["http.server.active_requests"].Data.(metricdata.Sum[int64]).DataPoints
+		Expect(active).Should(HaveLen(2))
+		for _, dp := range active {
+			Expect(dp.Value).Should(BeZero())
+		}
+
+		requestSize := metrics["http.server.request.size"].Data.(metricdata.Histogram).DataPoints
+		responseSize := metrics["http.server.response.size"].Data.(metricdata.Histogram).DataPoints
+		set := attribute.NewSet(
+			attribute.String("http.method", http.MethodPost),
+			attribute.String("http.route", "/users/:id"),
+			attribute.String("http.status_class", "2xx"),
+		)
+		for _, sizes := range [][]metricdata.HistogramDataPoint{requestSize, responseSize} {
+			Expect(sizes).Should(ContainElement(WithTransform(func(dp metricdata.HistogramDataPoint) attribute.Set {
+				return dp.Attributes
+			}, Equal(set))))
+		}
+		for _, dp := range responseSize {
+			if dp.Attributes.Equals(&set) {
+				Expect(dp.Sum).Should(BeEquivalentTo(2 * len("created")))
+			}
+		}
+	})
+
+	It("records the net/http requests", func() {
+		handler := TracingHandler("test", WithMetrics(mp))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "fail", http.StatusInternalServerError)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		// the methods sent by the clients are bounded
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/users/1", nil))
+
+		metrics := collectMetrics(reader)
+		active := metrics["http.server.active_requests"].Data.(metricdata.Sum[int64]).DataPoints
+		Expect(active).Should(HaveLen(2))
+		Expect(active).Should(ContainElement(WithTransform(func(dp metricdata.DataPoint[int64]) attribute.Set {
+			return dp.Attributes
+		}, Equal(attribute.NewSet(attribute.String("http.method", "_OTHER"))))))
+		duration := metrics["http.server.duration"].Data.(metricdata.Histogram).DataPoints
+		Expect(duration).Should(HaveLen(2))
+		for _, dp := range duration {
+			Expect(dp.Attributes.HasValue("http.route")).Should(BeFalse())
+			class, _ := dp.Attributes.Value("http.status_class")
+			Expect(class.AsString()).Should(Equal("5xx"))
+		}
+	})
+
+	It("records nothing without it", func() {
+		engine := gin.New()
+		engine.Use(Tracing("test"))
+		engine.GET("/", func(c *gin.Context) {})
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(collectMetrics(reader)).Should(BeEmpty())
+	})
+})
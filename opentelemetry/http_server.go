@@ -0,0 +1,165 @@
+package opentelemetry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HandlerOption is TracingWithOptions and TracingHandler option, the tracer options like WithPropagator
+// are accepted too.
+type HandlerOption interface {
+	applyHandler(*handlerOptions)
+}
+
+type handlerOptions struct {
+	tracer        []Option
+	routeName     func(r *http.Request) string
+	metrics       bool
+	meterProvider metric.MeterProvider
+}
+
+type handlerOptionFunc func(*handlerOptions)
+
+func (f handlerOptionFunc) applyHandler(opts *handlerOptions) {
+	f(opts)
+}
+
+func (o Option) applyHandler(opts *handlerOptions) {
+	opts.tracer = append(opts.tracer, o)
+}
+
+func newHandlerOptions(opts []HandlerOption) handlerOptions {
+	var op handlerOptions
+	for _, o := range opts {
+		o.applyHandler(&op)
+	}
+	return op
+}
+
+// WithRouteName with the extractor of the route template serving a request, like "/users/{id}", it is
+// called once the handler returns with the request passed to the middleware. Routers keeping the matched
+// route on a copy of the request can't be read this way, wrap each route handler with the middleware instead.
+//
+// The span is named after the route, or "HTTP <method>" if it is empty. It is ignored by TracingWithOptions,
+// which uses the gin route.
+func WithRouteName(routeName func(r *http.Request) string) HandlerOption {
+	return handlerOptionFunc(func(opts *handlerOptions) {
+		opts.routeName = routeName
+	})
+}
+
+// TracingHandler returns net/http middleware that will trace incoming requests like Tracing.
+// The service parameter should describe the name of the (virtual)
+// server handling the request.
+//
+//	mux := http.NewServeMux()
+//	http.ListenAndServe(addr, opentelemetry.TracingHandler("my-service")(mux))
+func TracingHandler(service string, opts ...HandlerOption) func(http.Handler) http.Handler {
+	op := newHandlerOptions(opts)
+	tracer := NewTracer(trace.SpanKindServer, op.tracer...)
+	metrics := newHTTPServerMetrics(op)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracer.Start(r.Context(), "HTTP "+r.Method, propagation.HeaderCarrier(r.Header),
+				httpServerStartOptions(service, "", r)...)
+
+			// pass the span through the request context
+			r = r.WithContext(ctx)
+			served := metrics.start(ctx, r.Method)
+			rw := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			var route string
+			if op.routeName != nil {
+				if route = op.routeName(r); route != "" {
+					span.SetName(route)
+					span.SetAttributes(semconv.HTTPRouteKey.String(route))
+				}
+			}
+			endHTTPServerSpan(ctx, tracer, span, rw.status(), nil)
+			served(ctx, route, rw.status(), r.ContentLength, rw.written)
+		})
+	}
+}
+
+// httpServerStartOptions are the span attributes shared by the gin and net/http middleware
+func httpServerStartOptions(service, route string, r *http.Request) []trace.SpanStartOption {
+	return []trace.SpanStartOption{
+		trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", r)...),
+		trace.WithAttributes(semconv.EndUserAttributesFromHTTPRequest(r)...),
+		trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(service, route, r)...),
+	}
+}
+
+// endHTTPServerSpan sets the response status and ends the span
+func endHTTPServerSpan(ctx context.Context, tracer *Tracer, span trace.Span, status int, err error) {
+	attrs := semconv.HTTPAttributesFromHTTPStatusCode(status)
+	spanStatus, spanMessage := semconv.SpanStatusFromHTTPStatusCode(status)
+	span.SetAttributes(attrs...)
+	span.SetStatus(spanStatus, spanMessage)
+	tracer.End(ctx, span, "", err)
+}
+
+// statusRecorder keeps the response status code and size
+type statusRecorder struct {
+	http.ResponseWriter
+	code    int
+	written int64
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// status is 200 if nothing was written, like net/http does
+func (w *statusRecorder) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// Flush implements http.Flusher
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.code == 0 {
+			w.code = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,94 @@
+package opentelemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/weecloudy/common/metadata"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("TracingHandler", func() {
+	var recorder *oteltest.Recorder
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("succeed", func() {
+		var hasMetadata bool
+		mux := http.NewServeMux()
+		mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+			_, hasMetadata = metadata.FromContext(r.Context())
+			Expect(trace.SpanFromContext(r.Context()).SpanContext().IsValid()).Should(BeTrue())
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("user"))
+		})
+		mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "fail", http.StatusInternalServerError)
+		})
+		handler := TracingHandler("test", WithRouteName(func(r *http.Request) string {
+			if strings.HasPrefix(r.URL.Path, "/users/") {
+				return "/users/{id}"
+			}
+			return ""
+		}))(mux)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set(serviceHeader, "caller-service")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		Expect(w.Body.String()).Should(Equal("user"))
+		Expect(hasMetadata).Should(BeTrue())
+
+		Expect(recorder).Should(oteltest.HaveSpan("/users/{id}",
+			oteltest.HaveKind(trace.SpanKindServer),
+			oteltest.HaveAttribute("http.route", "/users/{id}"),
+			oteltest.HaveAttribute("http.server_name", "test"),
+			oteltest.HaveAttribute("http.status_code", http.StatusOK),
+			oteltest.HaveStatus(codes.Ok),
+		))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/fail", nil))
+		Expect(recorder).Should(oteltest.HaveSpan("HTTP POST",
+			oteltest.HaveAttribute("http.status_code", http.StatusInternalServerError),
+			oteltest.HaveStatus(codes.Error),
+		))
+	})
+
+	It("takes the tracer options", func() {
+		handler := TracingHandler("test", WithPropagator(propagation.TraceContext{}))(http.NotFoundHandler())
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		span, ok := recorder.Span("HTTP GET")
+		Expect(ok).Should(BeTrue())
+		Expect(span.Parent.SpanID().String()).Should(Equal("b7ad6b7169203331"))
+	})
+
+	It("keeps the writer interfaces", func() {
+		handler := TracingHandler("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f, ok := w.(http.Flusher)
+			Expect(ok).Should(BeTrue())
+			f.Flush()
+			_, ok = w.(http.Hijacker)
+			Expect(ok).Should(BeTrue())
+		}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		Expect(w.Flushed).Should(BeTrue())
+		Expect(recorder).Should(oteltest.HaveSpan("HTTP GET", oteltest.HaveAttribute("http.status_code", http.StatusOK)))
+	})
+})
@@ -1,15 +1,8 @@
 package opentelemetry
 
 import (
-	"go.opentelemetry.io/otel"
-	"os"
-
-	"github.com/weecloudy/logger"
-	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
 // TracerProviderWithJaegerCollector use Jaeger exporter as tracer provider; sdk--http-->collector
@@ -24,24 +17,19 @@ import (
 // If neither values are provided for the endpoint, the default value of "http://localhost:14268/api/traces" will be used.
 // If neither values are provided for the username or the password, they will not be set since there is no default.
 func TracerProviderWithJaegerCollector(serverName string, options ...jaeger.CollectorEndpointOption) (*tracesdk.TracerProvider, error) {
+	return TracerProviderWithJaegerCollectorOptions(serverName, options)
+}
+
+// TracerProviderWithJaegerCollectorOptions is TracerProviderWithJaegerCollector with provider options,
+// like WithSampler.
+func TracerProviderWithJaegerCollectorOptions(serverName string, options []jaeger.CollectorEndpointOption, opts ...ProviderOption) (*tracesdk.TracerProvider, error) {
 	// Create the Jaeger exporter
 	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(options...))
 	if err != nil {
 		return nil, err
 	}
-	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithSampler(tracesdk.AlwaysSample()),
-		tracesdk.WithBatcher(exp),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(serverName),
-			semconv.ServiceVersionKey.String(Version()),
-			attribute.String(logger.RunEnv, os.Getenv(logger.RunEnv)),
-		)),
-	)
-	otel.SetTracerProvider(tp)
 
-	return tp, nil
+	return NewTracerProvider(serverName, exp, opts...)
 }
 
 // TracerProviderWithJaegerAgent use Jaeger agent as tracer provider; sdk--udp--> agent
@@ -55,22 +43,16 @@ func TracerProviderWithJaegerCollector(serverName string, options ...jaeger.Coll
 // The passed options will take precedence over any environment variables and default values
 // will be used if neither are provided.
 func TracerProviderWithJaegerAgent(serverName string, options ...jaeger.AgentEndpointOption) (*tracesdk.TracerProvider, error) {
+	return TracerProviderWithJaegerAgentOptions(serverName, options)
+}
+
+// TracerProviderWithJaegerAgentOptions is TracerProviderWithJaegerAgent with provider options, like WithSampler.
+func TracerProviderWithJaegerAgentOptions(serverName string, options []jaeger.AgentEndpointOption, opts ...ProviderOption) (*tracesdk.TracerProvider, error) {
 	// Create the Jaeger exporter
 	exp, err := jaeger.New(jaeger.WithAgentEndpoint(options...))
 	if err != nil {
 		return nil, err
 	}
-	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithSampler(tracesdk.AlwaysSample()),
-		tracesdk.WithBatcher(exp),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(serverName),
-			semconv.ServiceVersionKey.String(Version()),
-			attribute.String(logger.RunEnv, os.Getenv(logger.RunEnv)),
-		)),
-	)
-	otel.SetTracerProvider(tp)
 
-	return tp, nil
+	return NewTracerProvider(serverName, exp, opts...)
 }
@@ -0,0 +1,169 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// span events mirroring log entries
+const (
+	logEventName   = "log"
+	logSeverityKey = attribute.Key("log.severity")
+	logMessageKey  = attribute.Key("log.message")
+)
+
+const (
+	contextFieldKey   = "context"
+	defaultEventLevel = zapcore.InfoLevel
+)
+
+// SpanEventOption is NewSpanEventCore option.
+type SpanEventOption func(*spanEventOptions)
+
+type spanEventOptions struct {
+	level zapcore.LevelEnabler
+	allow map[string]bool
+}
+
+// WithMinLevel with the lowest level of the entries recorded as span events, info by default.
+func WithMinLevel(level zapcore.LevelEnabler) SpanEventOption {
+	return func(opts *spanEventOptions) {
+		opts.level = level
+	}
+}
+
+// WithFieldAllowlist with the keys of the fields recorded as event attributes,
+// no field is recorded by default so personal data doesn't leak into the traces.
+func WithFieldAllowlist(keys ...string) SpanEventOption {
+	return func(opts *spanEventOptions) {
+		for _, key := range keys {
+			opts.allow[key] = true
+		}
+	}
+}
+
+// ContextField returns the field carrying ctx to the span event core, it isn't encoded by the other cores.
+// The loggers returned by Logger carry it already.
+//
+//	log.Error("payment failed", opentelemetry.ContextField(ctx), zap.Error(err))
+func ContextField(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextFieldKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// SpanEvents returns the zap option teeing the logger core with NewSpanEventCore.
+//
+//	log := zap.New(core, opentelemetry.SpanEvents(opentelemetry.WithFieldAllowlist("order_id")))
+func SpanEvents(opts ...SpanEventOption) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, NewSpanEventCore(opts...))
+	})
+}
+
+// NewSpanEventCore returns a core recording the entries logged with a ContextField as events of the
+// span of the context, with the level, message and allowed fields. Error and higher entries set the
+// span status to error.
+func NewSpanEventCore(opts ...SpanEventOption) zapcore.Core {
+	op := spanEventOptions{level: defaultEventLevel, allow: make(map[string]bool)}
+	for _, o := range opts {
+		o(&op)
+	}
+	return &spanEventCore{LevelEnabler: op.level, allow: op.allow}
+}
+
+type spanEventCore struct {
+	zapcore.LevelEnabler
+	allow  map[string]bool
+	ctx    context.Context
+	fields []zapcore.Field
+}
+
+var _ zapcore.Core = (*spanEventCore)(nil)
+
+func (c *spanEventCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = c.fields[:len(c.fields):len(c.fields)]
+	clone.ctx, clone.fields = c.filter(clone.ctx, clone.fields, fields)
+	return &clone
+}
+
+func (c *spanEventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *spanEventCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ctx, allowed := c.filter(c.ctx, c.fields[:len(c.fields):len(c.fields)], fields)
+	if ctx == nil {
+		return nil
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		logSeverityKey.String(ent.Level.CapitalString()),
+		logMessageKey.String(ent.Message),
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range allowed {
+		f.AddTo(enc)
+	}
+	for key, value := range enc.Fields {
+		attrs = append(attrs, fieldAttribute(key, value))
+	}
+	span.AddEvent(logEventName, trace.WithTimestamp(ent.Time), trace.WithAttributes(attrs...))
+	if ent.Level >= zapcore.ErrorLevel {
+		span.SetStatus(codes.Error, ent.Message)
+	}
+	return nil
+}
+
+func (c *spanEventCore) Sync() error {
+	return nil
+}
+
+// filter returns the context of fields, or ctx if they have none, and appends the allowed fields to allowed
+func (c *spanEventCore) filter(ctx context.Context, allowed, fields []zapcore.Field) (context.Context, []zapcore.Field) {
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType && f.Key == contextFieldKey {
+			if fctx, ok := f.Interface.(context.Context); ok {
+				ctx = fctx
+			}
+			continue
+		}
+		if c.allow[f.Key] {
+			allowed = append(allowed, f)
+		}
+	}
+	return ctx, allowed
+}
+
+// fieldAttribute converts a value of a zap map encoder to an attribute
+func fieldAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return attribute.Int64(key, rv.Int())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return attribute.Int64(key, int64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return attribute.Float64(key, rv.Float())
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
@@ -0,0 +1,73 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("SpanEvents", func() {
+	var (
+		recorder *oteltest.Recorder
+		logs     *observer.ObservedLogs
+		log      *zap.Logger
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		var core zapcore.Core
+		core, logs = observer.New(zap.DebugLevel)
+		log = zap.New(core, SpanEvents(WithMinLevel(zap.InfoLevel), WithFieldAllowlist("order_id", "attempt", "error")))
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("records the entries as span events", func() {
+		ctx, span := NewTracer(trace.SpanKindInternal).Start(context.Background(), "work", nil)
+		l := Logger(ContextWithLogger(ctx, log))
+		l.Debug("too low")
+		l.Info("charging", zap.String("order_id", "o-1"), zap.Int("attempt", 2), zap.String("email", "a@b.c"))
+		l.Error("charge failed", zap.Error(errors.New("declined")))
+		log.Warn("no span")
+		span.End()
+
+		// the other cores still get every entry, without the context field
+		Expect(logs.Len()).Should(Equal(4))
+		Expect(logs.All()[1].ContextMap()).ShouldNot(HaveKey("context"))
+
+		Expect(recorder).Should(oteltest.HaveSpan("work", oteltest.HaveStatus(codes.Error, "charge failed")))
+		stub, _ := recorder.Span("work")
+		Expect(stub.Events).Should(HaveLen(2))
+		Expect(stub.Events[0].Name).Should(Equal("log"))
+		Expect(stub.Events[0].Attributes).Should(ConsistOf(
+			attribute.String("log.severity", "INFO"),
+			attribute.String("log.message", "charging"),
+			attribute.String("order_id", "o-1"),
+			attribute.Int64("attempt", 2),
+		))
+		Expect(stub.Events[1].Attributes).Should(ContainElement(attribute.String("error", "declined")))
+	})
+
+	It("takes the context of the entry fields", func() {
+		ctx, span := NewTracer(trace.SpanKindInternal).Start(context.Background(), "work", nil)
+		log.Warn("slow", ContextField(ctx))
+		span.End()
+
+		Expect(recorder).Should(oteltest.HaveSpan("work", oteltest.HaveStatus(codes.Unset)))
+		stub, _ := recorder.Span("work")
+		Expect(stub.Events).Should(HaveLen(1))
+		Expect(stub.Events[0].Attributes).Should(ContainElement(attribute.String("log.severity", "WARN")))
+	})
+})
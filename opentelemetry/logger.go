@@ -0,0 +1,104 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"github.com/weecloudy/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// log field keys of the span identity
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// LoggerKey is the gin context key of the request-scoped logger set by Logging.
+const LoggerKey = "weecloudy-tracer-logger"
+
+type loggerKey struct{}
+
+var (
+	defaultLoggerOnce sync.Once
+	defaultLogger     *zap.Logger
+)
+
+// fallbackLogger is the weecloudy/logger zap logger used without a request-scoped one, built once
+func fallbackLogger() *zap.Logger {
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = logger.NewZapLogger().Logger
+	})
+	return defaultLogger
+}
+
+// TraceFields returns the trace_id, span_id and trace_flags fields of the otel span of ctx,
+// or else of its opentracing jaeger span. It is empty without a valid span.
+func TraceFields(ctx context.Context) []zap.Field {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return []zap.Field{
+			zap.String(TraceIDKey, sc.TraceID().String()),
+			zap.String(SpanIDKey, sc.SpanID().String()),
+			zap.String(TraceFlagsKey, sc.TraceFlags().String()),
+		}
+	}
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		if sc, ok := span.Context().(jaeger.SpanContext); ok && sc.IsValid() {
+			flags := trace.TraceFlags(0)
+			if sc.IsSampled() {
+				flags = trace.FlagsSampled
+			}
+			// zero padded like the otel ids, jaeger omits the leading zeros
+			return []zap.Field{
+				zap.String(TraceIDKey, fmt.Sprintf("%016x%016x", sc.TraceID().High, sc.TraceID().Low)),
+				zap.String(SpanIDKey, fmt.Sprintf("%016x", uint64(sc.SpanID()))),
+				zap.String(TraceFlagsKey, flags.String()),
+			}
+		}
+	}
+	return nil
+}
+
+// ContextWithLogger returns a copy of ctx carrying l, the logger returned by Logger.
+func ContextWithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger returns the logger of ctx, set by ContextWithLogger or Logging, with the trace fields and
+// the ContextField of the current span of ctx. A weecloudy/logger zap logger is used if ctx has none.
+//
+//	opentelemetry.Logger(ctx).Info("order created", zap.String("order_id", id))
+func Logger(ctx context.Context) *zap.Logger {
+	l, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+	if !ok {
+		l = fallbackLogger()
+	}
+	if fields := TraceFields(ctx); len(fields) > 0 {
+		return l.With(append(fields, ContextField(ctx))...)
+	}
+	return l
+}
+
+// Logging returns middleware attaching a request-scoped logger, a weecloudy/logger zap logger if l is nil,
+// to the request context for Logger, and to the gin context under LoggerKey with the fields of the
+// request span. Use it after Tracing or the opentracing middleware.
+//
+//	engine.Use(opentelemetry.Tracing("my-service"), opentelemetry.Logging(nil))
+func Logging(l *zap.Logger) gin.HandlerFunc {
+	if l == nil {
+		l = fallbackLogger()
+	}
+	return func(c *gin.Context) {
+		ctx := ContextWithLogger(c.Request.Context(), l)
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(LoggerKey, Logger(ctx))
+
+		c.Next()
+	}
+}
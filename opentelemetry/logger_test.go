@@ -0,0 +1,84 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		recorder *oteltest.Recorder
+		logs     *observer.ObservedLogs
+		base     *zap.Logger
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		var core zapcore.Core
+		core, logs = observer.New(zap.InfoLevel)
+		base = zap.New(core)
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("adds the otel span fields", func() {
+		Expect(TraceFields(context.Background())).Should(BeEmpty())
+
+		ctx, span := NewTracer(trace.SpanKindInternal).Start(context.Background(), "work", nil)
+		defer span.End()
+		Logger(ContextWithLogger(ctx, base)).Info("hello")
+
+		Expect(logs.Len()).Should(Equal(1))
+		fields := logs.All()[0].ContextMap()
+		Expect(fields).Should(HaveKeyWithValue(TraceIDKey, span.SpanContext().TraceID().String()))
+		Expect(fields).Should(HaveKeyWithValue(SpanIDKey, span.SpanContext().SpanID().String()))
+		Expect(fields).Should(HaveKeyWithValue(TraceFlagsKey, "01"))
+	})
+
+	It("adds the opentracing span fields", func() {
+		tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+		defer closer.Close()
+		span := tracer.StartSpan("work")
+		defer span.Finish()
+		sc := span.Context().(jaeger.SpanContext)
+
+		fields := TraceFields(opentracing.ContextWithSpan(context.Background(), span))
+		Expect(fields).Should(HaveLen(3))
+		Expect(fields[0].String).Should(HaveLen(32))
+		Expect(fields[0].String).Should(HaveSuffix(sc.TraceID().String()))
+		Expect(fields[2].String).Should(Equal("01"))
+	})
+
+	It("attaches a request-scoped logger", func() {
+		var spanID string
+		engine := gin.New()
+		engine.Use(Tracing("test"), Logging(base))
+		engine.GET("/", func(c *gin.Context) {
+			spanID = trace.SpanFromContext(c.Request.Context()).SpanContext().SpanID().String()
+			c.MustGet(LoggerKey).(*zap.Logger).Info("gin")
+			Logger(c.Request.Context()).Info("request")
+			c.Status(http.StatusOK)
+		})
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(logs.Len()).Should(Equal(2))
+		for _, entry := range logs.All() {
+			Expect(entry.ContextMap()).Should(HaveKeyWithValue(SpanIDKey, spanID))
+		}
+	})
+})
@@ -0,0 +1,95 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric/global"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// NewMeterProvider collects the metrics of readers with the same service resource as NewTracerProvider
+// and sets it as the global meter provider, only the resource options of opts are used.
+func NewMeterProvider(serverName string, readers []sdkmetric.Reader, opts ...ProviderOption) *sdkmetric.MeterProvider {
+	op := providerOptions{}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	mpOpts := []sdkmetric.Option{
+		sdkmetric.WithResource(newResource(serverName, serviceVersion(op.version), op.detectors, op.attributes...)),
+	}
+	for _, r := range readers {
+		mpOpts = append(mpOpts, sdkmetric.WithReader(r))
+	}
+	mp := sdkmetric.NewMeterProvider(mpOpts...)
+	global.SetMeterProvider(mp)
+
+	return mp
+}
+
+// MeterProviderWithPrometheus use Prometheus exporter as meter provider; prometheus--http-->handler
+// The metrics are registered to a dedicated registry, with the go and process collectors, served by the
+// returned handler. The http.server.duration buckets of WithMetrics carry trace_id and span_id exemplars of
+// the sampled requests in the OpenMetrics format. Mount it on the application router, before the tracing middleware so the scrapes
+// aren't traced, or on its own server:
+//
+//	mp, handler, err := opentelemetry.MeterProviderWithPrometheus("my-service")
+//	engine.GET("/metrics", gin.WrapH(handler))
+//	engine.Use(opentelemetry.TracingWithOptions("my-service", opentelemetry.WithMetrics(mp)))
+//	// or
+//	go http.ListenAndServe(":9464", handler)
+func MeterProviderWithPrometheus(serverName string, options ...otelprometheus.Option) (*sdkmetric.MeterProvider, http.Handler, error) {
+	return MeterProviderWithPrometheusOptions(serverName, options)
+}
+
+// MeterProviderWithPrometheusOptions is MeterProviderWithPrometheus with the resource options of the
+// tracer provider, like WithServiceVersion, so the metrics describe the same resource as the spans.
+func MeterProviderWithPrometheusOptions(serverName string, options []otelprometheus.Option, opts ...ProviderOption) (*sdkmetric.MeterProvider, http.Handler, error) {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(collectors.NewGoCollector()); err != nil {
+		return nil, nil, err
+	}
+	if err := reg.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
+		return nil, nil, err
+	}
+	store := newExemplarStore()
+	exp, err := otelprometheus.New(append(options, otelprometheus.WithRegisterer(exemplarRegisterer{reg, store}))...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mp := NewMeterProvider(serverName, []sdkmetric.Reader{exp}, opts...)
+	exemplarStores.Store(mp, store)
+	// exemplars are only exposed in the OpenMetrics format
+	return mp, promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}), nil
+}
+
+// MeterProviderWithOTLP use OTLP exporter as meter provider; sdk--grpc-->collector
+// The metrics are pushed every interval, or every OTEL_METRIC_EXPORT_INTERVAL (60s by default) if it
+// isn't positive. This will use the OTEL_EXPORTER_OTLP_METRICS_* and OTEL_EXPORTER_OTLP_* environment
+// variables for configuration if no explicit option is provided.
+func MeterProviderWithOTLP(ctx context.Context, serverName string, interval time.Duration, options ...otlpmetricgrpc.Option) (*sdkmetric.MeterProvider, error) {
+	return MeterProviderWithOTLPOptions(ctx, serverName, interval, options)
+}
+
+// MeterProviderWithOTLPOptions is MeterProviderWithOTLP with the resource options of the tracer provider,
+// like WithServiceVersion, so the metrics describe the same resource as the spans.
+func MeterProviderWithOTLPOptions(ctx context.Context, serverName string, interval time.Duration, options []otlpmetricgrpc.Option, opts ...ProviderOption) (*sdkmetric.MeterProvider, error) {
+	exp, err := otlpmetricgrpc.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if interval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(interval))
+	}
+	return NewMeterProvider(serverName, []sdkmetric.Reader{sdkmetric.NewPeriodicReader(exp, readerOpts...)}, opts...), nil
+}
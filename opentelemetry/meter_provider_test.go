@@ -0,0 +1,109 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("MeterProvider", func() {
+	var saved metric.MeterProvider
+
+	BeforeEach(func() {
+		saved = global.MeterProvider()
+	})
+
+	AfterEach(func() {
+		global.SetMeterProvider(saved)
+	})
+
+	It("shares the tracer provider resource", func() {
+		reader := sdkmetric.NewManualReader()
+		mp := NewMeterProvider("metrics-test", []sdkmetric.Reader{reader}, WithServiceVersion("v1.2.3"), WithDetectors())
+		defer mp.Shutdown(context.Background())
+		Expect(global.MeterProvider()).Should(BeIdenticalTo(mp))
+
+		counter, err := global.Meter("test").Int64Counter("jobs")
+		Expect(err).ShouldNot(HaveOccurred())
+		counter.Add(context.Background(), 1)
+
+		var rm metricdata.ResourceMetrics
+		Expect(reader.Collect(context.Background(), &rm)).Should(Succeed())
+		name, _ := rm.Resource.Set().Value(semconv.ServiceNameKey)
+		version, _ := rm.Resource.Set().Value(semconv.ServiceVersionKey)
+		Expect(name.AsString()).Should(Equal("metrics-test"))
+		Expect(version.AsString()).Should(Equal("v1.2.3"))
+	})
+
+	It("serves the prometheus metrics", func() {
+		mp, handler, err := MeterProviderWithPrometheusOptions("metrics-test", nil, WithServiceVersion("v1.2.3"), WithDetectors())
+		Expect(err).ShouldNot(HaveOccurred())
+		defer mp.Shutdown(context.Background())
+
+		engine := gin.New()
+		engine.GET("/metrics", gin.WrapH(handler))
+		engine.Use(TracingWithOptions("test", WithMetrics(nil)))
+		engine.GET("/", func(c *gin.Context) {})
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(w.Code).Should(Equal(http.StatusOK))
+		Expect(w.Body.String()).Should(ContainSubstring(`http_server_duration_milliseconds_count{http_method="GET",http_route="/",http_status_class="2xx"`))
+		Expect(w.Body.String()).Should(ContainSubstring(`service_name="metrics-test"`))
+		Expect(w.Body.String()).Should(ContainSubstring(`service_version="v1.2.3"`))
+		// the scrapes are served before the middleware
+		Expect(w.Body.String()).ShouldNot(ContainSubstring(`http_route="/metrics"`))
+		Expect(w.Body.String()).Should(ContainSubstring("go_goroutines"))
+	})
+
+	It("serves the trace exemplars of the request durations", func() {
+		recorder := oteltest.Install()
+		defer recorder.Uninstall()
+		mp, handler, err := MeterProviderWithPrometheusOptions("metrics-test", nil, WithDetectors())
+		Expect(err).ShouldNot(HaveOccurred())
+		defer mp.Shutdown(context.Background())
+
+		engine := gin.New()
+		engine.GET("/metrics", gin.WrapH(handler))
+		engine.Use(TracingWithOptions("test", WithMetrics(mp)))
+		engine.GET("/users/:id", func(c *gin.Context) {})
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		span, ok := recorder.Span("/users/:id")
+		Expect(ok).Should(BeTrue())
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		Expect(w.Code).Should(Equal(http.StatusOK))
+		Expect(w.Body.String()).Should(MatchRegexp(
+			`http_server_duration_milliseconds_bucket\{http_method="GET",http_route="/users/:id",http_status_class="2xx",[^}]*\} 1 # \{trace_id="%s",span_id="%s"\}`,
+			span.SpanContext.TraceID(), span.SpanContext.SpanID()))
+		Expect(w.Body.String()).ShouldNot(MatchRegexp(`http_server_request_size_bytes_bucket\{[^}]*\} \d+ #`))
+	})
+
+	It("pushes the metrics with otlp", func() {
+		mp, err := MeterProviderWithOTLPOptions(context.Background(), "metrics-test", 0, nil, WithDetectors())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(global.MeterProvider()).Should(BeIdenticalTo(mp))
+
+		// there is no collector to flush to
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_ = mp.Shutdown(ctx)
+	})
+})
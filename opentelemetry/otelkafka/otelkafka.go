@@ -0,0 +1,127 @@
+// Package otelkafka traces segmentio/kafka-go producers and consumers with the opentelemetry package tracer.
+// Producer spans are injected into the message headers, consumer spans start a new trace linked to them.
+//
+//	w := otelkafka.NewWriter(&kafka.Writer{Addr: kafka.TCP("localhost:9092"), Topic: "orders"})
+//	err := w.WriteMessages(ctx, kafka.Message{Value: []byte("created")})
+//
+//	r := otelkafka.NewReader(kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, GroupID: "billing", Topic: "orders"}))
+//	err := r.Process(ctx, func(ctx context.Context, msg kafka.Message) error {
+//		return handle(ctx, msg)
+//	})
+package otelkafka
+
+import (
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+
+	"tracer/opentelemetry"
+)
+
+const defaultBatchWait = 100 * time.Millisecond
+
+const (
+	// offsetKey is the offset of the message in its partition
+	offsetKey = attribute.Key("messaging.kafka.message.offset")
+	// batchCountKey is the number of messages processed by a batch span
+	batchCountKey = attribute.Key("messaging.batch.message_count")
+)
+
+// Option is otelkafka option.
+type Option func(*options)
+
+type options struct {
+	attrs     []attribute.KeyValue
+	tracer    []opentelemetry.Option
+	noCommit  bool
+	batchWait time.Duration
+}
+
+// WithAttributes with extra attributes of every span, like messaging.kafka.client_id.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(opts *options) {
+		opts.attrs = append(opts.attrs, attrs...)
+	}
+}
+
+// WithTracerOptions with the options of the opentelemetry tracer creating the spans.
+func WithTracerOptions(opts ...opentelemetry.Option) Option {
+	return func(o *options) {
+		o.tracer = append(o.tracer, opts...)
+	}
+}
+
+// WithoutCommit with readers not committing the processed messages, required by the readers
+// without a consumer group.
+func WithoutCommit() Option {
+	return func(opts *options) {
+		opts.noCommit = true
+	}
+}
+
+// WithBatchWait with how long ProcessBatch waits for more messages once the first one is fetched,
+// 100ms by default.
+func WithBatchWait(d time.Duration) Option {
+	return func(opts *options) {
+		opts.batchWait = d
+	}
+}
+
+// HeaderCarrier adapts the headers of a message to propagation.TextMapCarrier.
+type HeaderCarrier struct {
+	msg *kafka.Message
+}
+
+// NewHeaderCarrier returns the carrier of the headers of msg.
+func NewHeaderCarrier(msg *kafka.Message) HeaderCarrier {
+	return HeaderCarrier{msg: msg}
+}
+
+// Get returns the value of the first header with key.
+func (c HeaderCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the value of the header with key, so retried messages don't carry stale contexts.
+func (c HeaderCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if h.Key == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys returns the header keys.
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// topicAttributes are the attributes of the messages sent or received on topic
+func topicAttributes(topic string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKey.String("kafka"),
+		semconv.MessagingDestinationKey.String(topic),
+		semconv.MessagingDestinationKindTopic,
+	}
+}
+
+// keyAttributes are the attributes of the key of msg
+func keyAttributes(msg *kafka.Message) []attribute.KeyValue {
+	if len(msg.Key) == 0 {
+		return nil
+	}
+	return []attribute.KeyValue{semconv.MessagingKafkaMessageKeyKey.String(string(msg.Key))}
+}
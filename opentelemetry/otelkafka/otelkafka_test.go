@@ -0,0 +1,204 @@
+package otelkafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+	"tracer/opentelemetry/oteltest"
+)
+
+func TestOtelkafka(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "otelkafka Suite")
+}
+
+// broker is an in-memory topic, written messages are fetched in order
+type broker struct {
+	mu        sync.Mutex
+	msgs      []kafka.Message
+	committed []kafka.Message
+	writeErr  error
+}
+
+func (b *broker) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.writeErr != nil {
+		return b.writeErr
+	}
+	for _, msg := range msgs {
+		msg.Topic = "orders"
+		msg.Offset = int64(len(b.msgs))
+		b.msgs = append(b.msgs, msg)
+	}
+	return nil
+}
+
+func (b *broker) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.msgs) == 0 {
+		b.mu.Unlock()
+		<-ctx.Done()
+		b.mu.Lock()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := b.msgs[0]
+	b.msgs = b.msgs[1:]
+	return msg, nil
+}
+
+func (b *broker) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.committed = append(b.committed, msgs...)
+	return nil
+}
+
+var _ = Describe("otelkafka", func() {
+	var (
+		recorder *oteltest.Recorder
+		b        *broker
+		writer   *Writer
+		reader   *Reader
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		b = &broker{}
+		writer = NewWriter(b)
+		reader = NewReader(b, WithBatchWait(10*time.Millisecond))
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("carries the context in the headers", func() {
+		msg := kafka.Message{Headers: []kafka.Header{{Key: "traceparent", Value: []byte("stale")}}}
+		carrier := NewHeaderCarrier(&msg)
+		carrier.Set("traceparent", "fresh")
+		carrier.Set("baggage", "k=v")
+
+		Expect(carrier.Get("traceparent")).Should(Equal("fresh"))
+		Expect(carrier.Get("missing")).Should(BeEmpty())
+		Expect(carrier.Keys()).Should(Equal([]string{"traceparent", "baggage"}))
+	})
+
+	It("links the consumer span to the producer span", func() {
+		ctx, parent := opentelemetry.NewTracer(trace.SpanKindInternal).Start(context.Background(), "parent", nil)
+		msg := kafka.Message{Topic: "orders", Key: []byte("order-1"), Value: []byte("created")}
+		Expect(writer.WriteMessages(ctx, msg)).Should(Succeed())
+		parent.End()
+		Expect(msg.Headers).Should(BeEmpty())
+
+		var handled context.Context
+		Expect(reader.Process(context.Background(), func(ctx context.Context, msg kafka.Message) error {
+			handled = ctx
+			return nil
+		})).Should(Succeed())
+		Expect(b.committed).Should(HaveLen(1))
+
+		Expect(recorder).Should(oteltest.HaveSpan("orders send",
+			oteltest.HaveKind(trace.SpanKindProducer),
+			oteltest.HaveAttribute("messaging.system", "kafka"),
+			oteltest.HaveAttribute("messaging.destination", "orders"),
+			oteltest.HaveAttribute("messaging.kafka.message_key", "order-1"),
+			oteltest.HaveAttribute("messaging.message_payload_size_bytes", 7),
+			oteltest.BeChildOf(parent),
+		))
+		producer, _ := recorder.Span("orders send")
+		consumer, ok := recorder.Span("orders process")
+		Expect(ok).Should(BeTrue())
+		Expect(consumer.SpanKind).Should(Equal(trace.SpanKindConsumer))
+		Expect(consumer.Parent.IsValid()).Should(BeFalse())
+		Expect(consumer.Links).Should(HaveLen(1))
+		Expect(consumer.Links[0].SpanContext.SpanID()).Should(Equal(producer.SpanContext.SpanID()))
+		Expect(trace.SpanContextFromContext(handled).SpanID()).Should(Equal(consumer.SpanContext.SpanID()))
+		Expect(recorder).Should(oteltest.HaveSpan("orders process",
+			oteltest.HaveAttribute("messaging.operation", "process"),
+			oteltest.HaveAttribute("messaging.kafka.partition", 0),
+			oteltest.HaveAttribute("messaging.kafka.message.offset", 0),
+		))
+	})
+
+	It("doesn't commit failed messages", func() {
+		Expect(writer.WriteMessages(context.Background(), kafka.Message{Topic: "orders"})).Should(Succeed())
+		err := errors.New("handler failed")
+		Expect(reader.Process(context.Background(), func(context.Context, kafka.Message) error {
+			return err
+		})).Should(Equal(err))
+
+		Expect(b.committed).Should(BeEmpty())
+		Expect(recorder).Should(oteltest.HaveSpan("orders process", oteltest.HaveStatus(codes.Error, "handler failed")))
+	})
+
+	It("records write errors", func() {
+		b.writeErr = kafka.WriteErrors{nil, errors.New("leader not available")}
+		Expect(writer.WriteMessages(context.Background(),
+			kafka.Message{Topic: "orders", Key: []byte("ok")},
+			kafka.Message{Topic: "orders", Key: []byte("ko")},
+		)).ShouldNot(Succeed())
+
+		spans := recorder.Spans()
+		Expect(spans).Should(HaveLen(2))
+		Expect(spans[0].Status.Code).Should(Equal(codes.Ok))
+		Expect(spans[1].Status.Code).Should(Equal(codes.Error))
+	})
+
+	It("processes batches", func() {
+		for i := 0; i < 3; i++ {
+			Expect(writer.WriteMessages(context.Background(), kafka.Message{Topic: "orders"})).Should(Succeed())
+		}
+
+		var batch []kafka.Message
+		Expect(reader.ProcessBatch(context.Background(), 2, func(ctx context.Context, msgs []kafka.Message) error {
+			batch = msgs
+			return nil
+		})).Should(Succeed())
+		Expect(batch).Should(HaveLen(2))
+		Expect(b.committed).Should(HaveLen(2))
+
+		// the last message doesn't fill the batch, it is processed after the wait
+		Expect(reader.ProcessBatch(context.Background(), 2, func(ctx context.Context, msgs []kafka.Message) error {
+			batch = msgs
+			return nil
+		})).Should(Succeed())
+		Expect(batch).Should(HaveLen(1))
+		Expect(b.committed).Should(HaveLen(3))
+
+		Expect(recorder).Should(oteltest.HaveSpan("orders process",
+			oteltest.HaveKind(trace.SpanKindConsumer),
+			oteltest.HaveAttribute("messaging.batch.message_count", 2),
+		))
+		var processed int
+		for _, span := range recorder.Spans() {
+			if span.Name == "orders process" {
+				processed += len(span.Links)
+			}
+		}
+		Expect(processed).Should(Equal(3))
+	})
+
+	It("returns the first fetch error", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(reader.ProcessBatch(ctx, 2, func(context.Context, []kafka.Message) error {
+			return nil
+		})).Should(MatchError(context.Canceled))
+		Expect(recorder.Spans()).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,130 @@
+package otelkafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+)
+
+// MessageReader is the method of *kafka.Reader traced by Reader.
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+}
+
+// Committer commits the processed messages, implemented by *kafka.Reader.
+type Committer interface {
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Reader processes the fetched messages in consumer spans linked to their producer spans.
+type Reader struct {
+	r         MessageReader
+	tracer    *opentelemetry.Tracer
+	batch     *opentelemetry.Tracer
+	attrs     []attribute.KeyValue
+	commit    bool
+	batchWait time.Duration
+}
+
+// NewReader returns the traced r, the processed messages are committed if r is a Committer
+// unless WithoutCommit is given.
+func NewReader(r MessageReader, opts ...Option) *Reader {
+	op := options{batchWait: defaultBatchWait}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	attrs := op.attrs
+	if kr, ok := r.(interface{ Config() kafka.ReaderConfig }); ok && kr.Config().GroupID != "" {
+		attrs = append([]attribute.KeyValue{semconv.MessagingKafkaConsumerGroupKey.String(kr.Config().GroupID)}, attrs...)
+	}
+	_, committer := r.(Committer)
+	return &Reader{
+		r:         r,
+		tracer:    opentelemetry.NewTracer(trace.SpanKindConsumer, append([]opentelemetry.Option{opentelemetry.WithLink()}, op.tracer...)...),
+		batch:     opentelemetry.NewTracer(trace.SpanKindConsumer, op.tracer...),
+		attrs:     attrs,
+		commit:    committer && !op.noCommit,
+		batchWait: op.batchWait,
+	}
+}
+
+// Process fetches the next message and calls handler with the context of its span, the message is
+// committed once handler succeeds. The handler error is returned and the message isn't committed.
+func (r *Reader) Process(ctx context.Context, handler func(ctx context.Context, msg kafka.Message) error) error {
+	msg, err := r.r.FetchMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	attrs := append(r.topicAttributes(msg.Topic), keyAttributes(&msg)...)
+	attrs = append(attrs, semconv.MessagingKafkaPartitionKey.Int(msg.Partition), offsetKey.Int64(msg.Offset))
+	spanCtx, span := r.tracer.Start(ctx, msg.Topic+" process", NewHeaderCarrier(&msg), trace.WithAttributes(attrs...))
+	err = handler(spanCtx, msg)
+	if err == nil && r.commit {
+		err = r.r.(Committer).CommitMessages(spanCtx, msg)
+	}
+	r.tracer.End(spanCtx, span, msg.Value, err)
+
+	return err
+}
+
+// ProcessBatch fetches up to size messages and calls handler with the context of a span linked to all
+// their producer spans. It waits for the first message as long as ctx is alive, then for the next ones
+// at most the WithBatchWait duration. The batch is committed once handler succeeds.
+func (r *Reader) ProcessBatch(ctx context.Context, size int, handler func(ctx context.Context, msgs []kafka.Message) error) error {
+	msg, err := r.r.FetchMessage(ctx)
+	if err != nil {
+		return err
+	}
+	msgs := []kafka.Message{msg}
+
+	// a fetch error after the first message still processes the batch and is returned afterwards
+	var fetchErr error
+	waitCtx, cancel := context.WithTimeout(ctx, r.batchWait)
+	for len(msgs) < size {
+		msg, err := r.r.FetchMessage(waitCtx)
+		if err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				fetchErr = err
+			}
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+	cancel()
+
+	links := make([]trace.Link, 0, len(msgs))
+	for i := range msgs {
+		remote := trace.SpanContextFromContext(r.batch.Extract(context.Background(), NewHeaderCarrier(&msgs[i])))
+		if remote.IsValid() {
+			links = append(links, trace.Link{
+				SpanContext: remote,
+				Attributes:  []attribute.KeyValue{semconv.MessagingKafkaPartitionKey.Int(msgs[i].Partition), offsetKey.Int64(msgs[i].Offset)},
+			})
+		}
+	}
+	attrs := append(r.topicAttributes(msgs[0].Topic), batchCountKey.Int(len(msgs)))
+	spanCtx, span := r.batch.Start(ctx, msgs[0].Topic+" process", nil, trace.WithAttributes(attrs...), trace.WithLinks(links...))
+	err = handler(spanCtx, msgs)
+	if err == nil && r.commit {
+		err = r.r.(Committer).CommitMessages(spanCtx, msgs...)
+	}
+	r.batch.End(spanCtx, span, nil, err)
+
+	if err != nil {
+		return err
+	}
+	return fetchErr
+}
+
+func (r *Reader) topicAttributes(topic string) []attribute.KeyValue {
+	return append(append(topicAttributes(topic), semconv.MessagingOperationProcess), r.attrs...)
+}
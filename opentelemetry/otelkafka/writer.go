@@ -0,0 +1,75 @@
+package otelkafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+)
+
+// MessageWriter is the method of *kafka.Writer traced by Writer.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Writer creates a producer span per written message and injects it into the message headers.
+type Writer struct {
+	w      MessageWriter
+	topic  string
+	tracer *opentelemetry.Tracer
+	attrs  []attribute.KeyValue
+}
+
+// NewWriter returns the traced w, messages without a topic are sent to the topic of a *kafka.Writer.
+func NewWriter(w MessageWriter, opts ...Option) *Writer {
+	op := options{}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	var topic string
+	if kw, ok := w.(*kafka.Writer); ok {
+		topic = kw.Topic
+	}
+	return &Writer{
+		w:      w,
+		topic:  topic,
+		tracer: opentelemetry.NewTracer(trace.SpanKindProducer, op.tracer...),
+		attrs:  op.attrs,
+	}
+}
+
+// WriteMessages writes msgs with the underlying writer, the spans end with the write error of their message.
+func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	// inject into copies, the caller's messages and headers are left untouched
+	msgs = append([]kafka.Message(nil), msgs...)
+	spans := make([]trace.Span, len(msgs))
+	ctxs := make([]context.Context, len(msgs))
+	for i := range msgs {
+		msg := &msgs[i]
+		msg.Headers = append([]kafka.Header(nil), msg.Headers...)
+
+		topic := msg.Topic
+		if topic == "" {
+			topic = w.topic
+		}
+		attrs := append(append(topicAttributes(topic), keyAttributes(msg)...), w.attrs...)
+		ctxs[i], spans[i] = w.tracer.Start(ctx, topic+" send", NewHeaderCarrier(msg), trace.WithAttributes(attrs...))
+	}
+
+	err := w.w.WriteMessages(ctx, msgs...)
+
+	// kafka.WriteErrors holds the error of every message of a batch
+	werrs, ok := err.(kafka.WriteErrors)
+	for i := range msgs {
+		merr := err
+		if ok && len(werrs) == len(msgs) {
+			merr = werrs[i]
+		}
+		w.tracer.End(ctxs[i], spans[i], msgs[i].Value, merr)
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+// Package otelredis traces go-redis commands and pipelines as client spans of the opentelemetry package tracer.
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	otelredis.InstrumentClient(rdb)
+package otelredis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+)
+
+const defaultMaxStatementLength = 256
+
+const (
+	// databaseIndexKey is the index of the database selected by the client
+	databaseIndexKey = attribute.Key("db.redis.database_index")
+	// numCmdKey is the number of commands of a pipeline
+	numCmdKey = attribute.Key("db.redis.num_cmd")
+)
+
+// Option is otelredis option.
+type Option func(*options)
+
+type options struct {
+	maxStatementLength int
+	attrs              []attribute.KeyValue
+	tracer             []opentelemetry.Option
+}
+
+// WithMaxStatementLength with the length db.statement is truncated to, 256 by default.
+// The arguments are not recorded if it is negative.
+func WithMaxStatementLength(n int) Option {
+	return func(opts *options) {
+		opts.maxStatementLength = n
+	}
+}
+
+// WithAttributes with extra attributes of every span, like net.peer.name.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(opts *options) {
+		opts.attrs = append(opts.attrs, attrs...)
+	}
+}
+
+// WithTracerOptions with the options of the opentelemetry tracer creating the spans.
+func WithTracerOptions(opts ...opentelemetry.Option) Option {
+	return func(o *options) {
+		o.tracer = append(o.tracer, opts...)
+	}
+}
+
+// InstrumentClient adds the hook to rdb with the peer and database index attributes of its options.
+func InstrumentClient(rdb *redis.Client, opts ...Option) {
+	o := rdb.Options()
+	attrs := []attribute.KeyValue{databaseIndexKey.Int(o.DB)}
+	if host, port, err := net.SplitHostPort(o.Addr); err == nil {
+		attrs = append(attrs, semconv.NetPeerNameKey.String(host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, semconv.NetPeerPortKey.Int(p))
+		}
+	}
+	rdb.AddHook(NewHook(append([]Option{WithAttributes(attrs...)}, opts...)...))
+}
+
+// NewHook returns a redis.Hook creating a span per command and per pipeline,
+// add it with AddHook to any client, cluster client or ring.
+func NewHook(opts ...Option) redis.Hook {
+	op := options{maxStatementLength: defaultMaxStatementLength}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	return &hook{
+		tracer: opentelemetry.NewTracer(trace.SpanKindClient, op.tracer...),
+		attrs:  append([]attribute.KeyValue{semconv.DBSystemRedis}, op.attrs...),
+		maxLen: op.maxStatementLength,
+	}
+}
+
+type hook struct {
+	tracer *opentelemetry.Tracer
+	attrs  []attribute.KeyValue
+	maxLen int
+}
+
+var _ redis.Hook = (*hook)(nil)
+
+func (h *hook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	attrs := append(h.attrs[:len(h.attrs):len(h.attrs)], semconv.DBOperationKey.String(cmd.FullName()))
+	if h.maxLen >= 0 {
+		attrs = append(attrs, semconv.DBStatementKey.String(h.statement(cmd)))
+	}
+	ctx, _ = h.tracer.Start(ctx, cmd.FullName(), nil, trace.WithAttributes(attrs...))
+
+	return ctx, nil
+}
+
+func (h *hook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.tracer.End(ctx, trace.SpanFromContext(ctx), nil, cmdError(cmd))
+	return nil
+}
+
+func (h *hook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, 0, len(cmds))
+	statements := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.FullName())
+		if h.maxLen >= 0 {
+			statements = append(statements, h.statement(cmd))
+		}
+	}
+
+	attrs := append(h.attrs[:len(h.attrs):len(h.attrs)],
+		semconv.DBOperationKey.String(strings.Join(names, " ")),
+		numCmdKey.Int(len(cmds)),
+	)
+	if h.maxLen >= 0 {
+		attrs = append(attrs, semconv.DBStatementKey.String(truncate(strings.Join(statements, "\n"), h.maxLen)))
+	}
+	ctx, _ = h.tracer.Start(ctx, "pipeline", nil, trace.WithAttributes(attrs...))
+
+	return ctx, nil
+}
+
+func (h *hook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if err = cmdError(cmd); err != nil {
+			break
+		}
+	}
+	h.tracer.End(ctx, trace.SpanFromContext(ctx), nil, err)
+	return nil
+}
+
+// statement joins the command arguments, truncated to maxLen
+func (h *hook) statement(cmd redis.Cmder) string {
+	var b strings.Builder
+	for i, arg := range cmd.Args() {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		switch v := arg.(type) {
+		case string:
+			b.WriteString(v)
+		case []byte:
+			b.Write(v)
+		default:
+			fmt.Fprint(&b, v)
+		}
+		if b.Len() > h.maxLen {
+			break
+		}
+	}
+	return truncate(b.String(), h.maxLen)
+}
+
+// truncate cuts s to at most n bytes, backing off to a rune boundary to keep it valid UTF-8
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// cmdError is the error of cmd, redis.Nil only reports a missing key
+func cmdError(cmd redis.Cmder) error {
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package otelredis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+	"tracer/opentelemetry/oteltest"
+)
+
+func TestOtelredis(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "otelredis Suite")
+}
+
+var _ = Describe("otelredis", func() {
+	var (
+		recorder *oteltest.Recorder
+		server   *miniredis.Miniredis
+		rdb      *redis.Client
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		var err error
+		server, err = miniredis.Run()
+		Expect(err).ShouldNot(HaveOccurred())
+		rdb = redis.NewClient(&redis.Options{Addr: server.Addr()})
+		InstrumentClient(rdb, WithMaxStatementLength(16))
+	})
+
+	AfterEach(func() {
+		_ = rdb.Close()
+		server.Close()
+		recorder.Uninstall()
+	})
+
+	It("traces commands", func() {
+		ctx, parent := opentelemetry.NewTracer(trace.SpanKindInternal).Start(context.Background(), "parent", nil)
+		Expect(rdb.Set(ctx, "key", strings.Repeat("v", 32), 0).Err()).Should(Succeed())
+		Expect(rdb.Get(ctx, "missing").Err()).Should(Equal(redis.Nil))
+		parent.End()
+
+		Expect(recorder).Should(oteltest.HaveSpan("set",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveAttribute("db.system", "redis"),
+			oteltest.HaveAttribute("db.operation", "set"),
+			oteltest.HaveAttribute("db.statement", "set key vvvvvvvv..."),
+			oteltest.HaveAttribute("db.redis.database_index", 0),
+			oteltest.HaveAttribute("net.peer.name", "127.0.0.1"),
+			oteltest.BeChildOf(parent),
+		))
+		Expect(recorder).Should(oteltest.HaveSpan("get",
+			oteltest.HaveAttribute("db.statement", "get missing"),
+			oteltest.HaveStatus(codes.Ok),
+		))
+	})
+
+	It("traces errors", func() {
+		Expect(rdb.Set(context.Background(), "key", "v", 0).Err()).Should(Succeed())
+		Expect(rdb.Incr(context.Background(), "key").Err()).Should(HaveOccurred())
+
+		Expect(recorder).Should(oteltest.HaveSpan("incr", oteltest.HaveStatus(codes.Error)))
+	})
+
+	It("traces pipelines", func() {
+		_, err := rdb.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+			pipe.Set(context.Background(), "a", "1", 0)
+			pipe.Incr(context.Background(), "a")
+			return nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(recorder).Should(oteltest.HaveSpan("pipeline",
+			oteltest.HaveAttribute("db.operation", "set incr"),
+			oteltest.HaveAttribute("db.redis.num_cmd", 2),
+			oteltest.HaveAttribute("db.statement", "set a 1\nincr a"),
+			oteltest.HaveStatus(codes.Ok),
+		))
+	})
+
+	It("hides the arguments", func() {
+		hooked := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer hooked.Close()
+		hooked.AddHook(NewHook(WithMaxStatementLength(-1)))
+		Expect(hooked.Ping(context.Background()).Err()).Should(Succeed())
+
+		Expect(recorder).Should(oteltest.HaveSpan("ping", oteltest.HaveAttribute("db.operation", "ping")))
+		span, _ := recorder.Span("ping")
+		Expect(span).ShouldNot(oteltest.HaveAttribute("db.statement"))
+	})
+
+	It("truncates the statement on a rune boundary", func() {
+		// 14 ASCII bytes then a 3 bytes rune crossing the 16 bytes limit
+		Expect(rdb.Set(context.Background(), "key", "value-世界", 0).Err()).Should(Succeed())
+
+		span, ok := recorder.Span("set")
+		Expect(ok).Should(BeTrue())
+		Expect(span).Should(oteltest.HaveAttribute("db.statement", "set key value-..."))
+		Expect(truncate("value-世界", 8)).Should(Equal("value-..."))
+		Expect(utf8.ValidString(truncate("世界", 2))).Should(BeTrue())
+	})
+})
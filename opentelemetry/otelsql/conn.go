@@ -0,0 +1,324 @@
+package otelsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// conn traces the calls of a driver connection, the optional interfaces it doesn't
+// implement return driver.ErrSkip so database/sql falls back to the others
+type conn struct {
+	driver.Conn
+	t *tracer
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	ctx, span := c.t.start(ctx, spanPrepare, query)
+
+	var (
+		s   driver.Stmt
+		err error
+	)
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	c.t.end(ctx, span, err)
+	if err != nil {
+		return nil, err
+	}
+	checker, _ := c.Conn.(driver.NamedValueChecker)
+	return &stmt{Stmt: s, t: c.t, query: query, checker: checker}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	spanCtx, span := c.t.start(ctx, spanBegin, "")
+
+	var (
+		dtx driver.Tx
+		err error
+	)
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		dtx, err = bc.BeginTx(spanCtx, opts)
+	} else if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		// the options can't be passed to Begin, fail like database/sql does for such drivers
+		err = errors.New("otelsql: driver does not support non-default isolation level")
+	} else if opts.ReadOnly {
+		err = errors.New("otelsql: driver does not support read-only transactions")
+	} else {
+		dtx, err = c.Conn.Begin()
+	}
+	c.t.end(spanCtx, span, err)
+	if err != nil {
+		return nil, err
+	}
+	// commit and rollback are siblings of begin
+	return &tx{Tx: dtx, t: c.t, ctx: ctx}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.t.start(ctx, spanExec, query)
+	res, err := execer.ExecContext(ctx, query, args)
+	c.t.endExec(ctx, span, res, err)
+
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.t.start(ctx, spanQuery, query)
+	r, err := queryer.QueryContext(ctx, query, args)
+
+	return c.t.endQuery(ctx, span, r, err)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt traces the calls of a prepared statement
+type stmt struct {
+	driver.Stmt
+	t     *tracer
+	query string
+	// checker of the conn, database/sql only uses it if the stmt isn't a checker
+	checker driver.NamedValueChecker
+}
+
+var (
+	_ driver.Stmt              = (*stmt)(nil)
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+	_ driver.ColumnConverter   = (*stmt)(nil)
+)
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := s.t.start(ctx, spanExec, s.query)
+
+	var (
+		res driver.Result
+		err error
+	)
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = ec.ExecContext(ctx, args)
+	} else if values, verr := namedValuesToValues(args); verr != nil {
+		err = verr
+	} else {
+		res, err = s.Stmt.Exec(values)
+	}
+	s.t.endExec(ctx, span, res, err)
+
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := s.t.start(ctx, spanQuery, s.query)
+
+	var (
+		r   driver.Rows
+		err error
+	)
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		r, err = qc.QueryContext(ctx, args)
+	} else if values, verr := namedValuesToValues(args); verr != nil {
+		err = verr
+	} else {
+		r, err = s.Stmt.Query(values)
+	}
+
+	return s.t.endQuery(ctx, span, r, err)
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if s.checker != nil {
+		return s.checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ColumnConverter is used once CheckNamedValue skipped, like database/sql without the wrapper
+func (s *stmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("otelsql: driver does not support the use of named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// tx traces the end of a transaction
+type tx struct {
+	driver.Tx
+	t   *tracer
+	ctx context.Context
+}
+
+func (t *tx) Commit() error {
+	ctx, span := t.t.start(t.ctx, spanCommit, "")
+	err := t.Tx.Commit()
+	t.t.end(ctx, span, err)
+
+	return err
+}
+
+func (t *tx) Rollback() error {
+	ctx, span := t.t.start(t.ctx, spanRollback, "")
+	err := t.Tx.Rollback()
+	t.t.end(ctx, span, err)
+
+	return err
+}
+
+// rows ends the query span once closed or failed, the optional interfaces fall back to the
+// database/sql defaults
+type rows struct {
+	driver.Rows
+	t    *tracer
+	ctx  context.Context
+	span trace.Span
+	once sync.Once
+}
+
+var (
+	_ driver.Rows                           = (*rows)(nil)
+	_ driver.RowsNextResultSet              = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeLength           = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*rows)(nil)
+)
+
+func (r *rows) end(err error) {
+	r.once.Do(func() {
+		r.t.end(r.ctx, r.span, err)
+	})
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err != nil && err != io.EOF {
+		r.end(err)
+	}
+	return err
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.end(err)
+
+	return err
+}
+
+func (r *rows) HasNextResultSet() bool {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *rows) NextResultSet() error {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return ct.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return ct.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(index int) (int64, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return ct.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(index int) (bool, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return ct.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return ct.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
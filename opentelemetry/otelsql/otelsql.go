@@ -0,0 +1,257 @@
+// Package otelsql wraps database/sql drivers to trace every Query, Exec, Prepare, Begin, Commit and Rollback
+// as a client span of the opentelemetry package tracer.
+//
+//	db, err := otelsql.Open("mysql", dsn, otelsql.WithDBSystem("mysql"), otelsql.WithSanitizer(otelsql.SanitizeSQL))
+//
+// or register the wrapped driver once and open it by name:
+//
+//	name, err := otelsql.Register("mysql", otelsql.WithDBSystem("mysql"))
+//	db, err := sql.Open(name, dsn)
+package otelsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+)
+
+// Option is otelsql option.
+type Option func(*options)
+
+type options struct {
+	system    string
+	name      string
+	sanitizer func(query string) string
+	tracer    []opentelemetry.Option
+}
+
+// WithDBSystem with the db.system attribute like mysql or postgresql, other_sql by default.
+func WithDBSystem(system string) Option {
+	return func(opts *options) {
+		opts.system = system
+	}
+}
+
+// WithDBName with the db.name attribute, the database being accessed.
+func WithDBName(name string) Option {
+	return func(opts *options) {
+		opts.name = name
+	}
+}
+
+// WithSanitizer with the function removing sensitive values from the statements before they are
+// recorded as db.statement, see SanitizeSQL. Statements are recorded as is without it.
+func WithSanitizer(sanitizer func(query string) string) Option {
+	return func(opts *options) {
+		opts.sanitizer = sanitizer
+	}
+}
+
+// WithTracerOptions with the options of the opentelemetry tracer creating the spans.
+func WithTracerOptions(opts ...opentelemetry.Option) Option {
+	return func(o *options) {
+		o.tracer = append(o.tracer, opts...)
+	}
+}
+
+var literalRegexp = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// SanitizeSQL replaces the single quoted strings and numbers of query with ?, double quoted
+// identifiers are kept. The parameters of prepared statements are never recorded.
+func SanitizeSQL(query string) string {
+	return literalRegexp.ReplaceAllString(query, "?")
+}
+
+var (
+	registerMu sync.Mutex
+	registered = make(map[string]string)
+)
+
+// Register registers the traced wrapper of the driver registered as driverName and returns its name.
+// Registering the same driver again returns the name of the first registration and ignores opts.
+func Register(driverName string, opts ...Option) (string, error) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	if name, ok := registered[driverName]; ok {
+		return name, nil
+	}
+	// database/sql doesn't expose the drivers, get it from a db which isn't connected yet
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return "", err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	name := fmt.Sprintf("otelsql-%s", driverName)
+	sql.Register(name, WrapDriver(d, opts...))
+	registered[driverName] = name
+
+	return name, nil
+}
+
+// Open opens a db of the registered driverName traced with opts, like sql.Open.
+func Open(driverName, dataSourceName string, opts ...Option) (*sql.DB, error) {
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	wrapped := WrapDriver(d, opts...).(*otelDriver)
+	connector, err := wrapped.OpenConnector(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// WrapDriver returns the traced wrapper of d.
+func WrapDriver(d driver.Driver, opts ...Option) driver.Driver {
+	op := options{system: "other_sql"}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	attrs := []attribute.KeyValue{semconv.DBSystemKey.String(op.system)}
+	if op.name != "" {
+		attrs = append(attrs, semconv.DBNameKey.String(op.name))
+	}
+	return &otelDriver{
+		Driver: d,
+		t: &tracer{
+			tracer:    opentelemetry.NewTracer(trace.SpanKindClient, op.tracer...),
+			attrs:     attrs,
+			sanitizer: op.sanitizer,
+		},
+	}
+}
+
+// tracer starts the spans of a driver
+type tracer struct {
+	tracer    *opentelemetry.Tracer
+	attrs     []attribute.KeyValue
+	sanitizer func(query string) string
+}
+
+// span names
+const (
+	spanQuery    = "sql.query"
+	spanExec     = "sql.exec"
+	spanPrepare  = "sql.prepare"
+	spanBegin    = "sql.begin"
+	spanCommit   = "sql.commit"
+	spanRollback = "sql.rollback"
+)
+
+// rowsAffectedKey is the number of rows changed by an exec
+const rowsAffectedKey = attribute.Key("db.rows_affected")
+
+func (t *tracer) start(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	attrs := t.attrs
+	if query != "" {
+		if t.sanitizer != nil {
+			query = t.sanitizer(query)
+		}
+		attrs = append(attrs[:len(attrs):len(attrs)], semconv.DBStatementKey.String(query))
+	}
+	return t.tracer.Start(ctx, name, nil, trace.WithAttributes(attrs...))
+}
+
+// end ends the span, driver.ErrSkip only asks database/sql to fall back to another method
+func (t *tracer) end(ctx context.Context, span trace.Span, err error, kv ...attribute.KeyValue) {
+	if err == driver.ErrSkip {
+		err = nil
+	}
+	t.tracer.End(ctx, span, nil, err, kv...)
+}
+
+func (t *tracer) endExec(ctx context.Context, span trace.Span, res driver.Result, err error) {
+	var kv []attribute.KeyValue
+	if err == nil && res != nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			kv = append(kv, rowsAffectedKey.Int64(n))
+		}
+	}
+	t.end(ctx, span, err, kv...)
+}
+
+// endQuery ends the span once the rows are read
+func (t *tracer) endQuery(ctx context.Context, span trace.Span, r driver.Rows, err error) (driver.Rows, error) {
+	if err != nil {
+		t.end(ctx, span, err)
+		return nil, err
+	}
+	return &rows{Rows: r, t: t, ctx: ctx, span: span}, nil
+}
+
+type otelDriver struct {
+	driver.Driver
+	t *tracer
+}
+
+var (
+	_ driver.Driver        = (*otelDriver)(nil)
+	_ driver.DriverContext = (*otelDriver)(nil)
+)
+
+func (d *otelDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, t: d.t}, nil
+}
+
+// OpenConnector implements driver.DriverContext, the dsn is opened on every connect without it
+func (d *otelDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{Connector: c, driver: d}, nil
+	}
+	return &connector{Connector: dsnConnector{dsn: name, driver: d.Driver}, driver: d}, nil
+}
+
+type connector struct {
+	driver.Connector
+	driver *otelDriver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, t: c.driver.t}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
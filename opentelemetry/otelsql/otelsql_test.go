@@ -0,0 +1,237 @@
+package otelsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry"
+	"tracer/opentelemetry/oteltest"
+)
+
+func TestOtelsql(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "otelsql Suite")
+}
+
+var errBadQuery = errors.New("bad query")
+
+// fakeDriver answers every query with a single row holding 1, "fail" queries return errBadQuery
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == "fail" {
+		return nil, errBadQuery
+	}
+	return driver.RowsAffected(2), nil
+}
+
+func (fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if query == "fail" {
+		return nil, errBadQuery
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{ done bool }
+
+func (*fakeRows) Columns() []string { return []string{"n"} }
+func (*fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+// point is only accepted by the Conn-level checker of checkerDriver
+type point struct{ x, y int }
+
+// checkerDriver checks the arguments on its Conn only, like pgx stdlib
+type checkerDriver struct{}
+
+func (checkerDriver) Open(string) (driver.Conn, error) { return checkerConn{}, nil }
+
+type checkerConn struct{ fakeConn }
+
+func (checkerConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if p, ok := nv.Value.(point); ok {
+		nv.Value = fmt.Sprintf("(%d,%d)", p.x, p.y)
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+	sql.Register("fake-checker", checkerDriver{})
+}
+
+var _ = Describe("otelsql", func() {
+	var (
+		recorder *oteltest.Recorder
+		db       *sql.DB
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		var err error
+		db, err = Open("fake", "", WithDBSystem("mysql"), WithDBName("orders"), WithSanitizer(SanitizeSQL))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = db.Close()
+		recorder.Uninstall()
+	})
+
+	It("traces queries and execs", func() {
+		ctx, parent := opentelemetry.NewTracer(trace.SpanKindInternal).Start(context.Background(), "parent", nil)
+
+		var n int
+		Expect(db.QueryRowContext(ctx, "SELECT n FROM t WHERE name = 'bob' AND id = 42").Scan(&n)).Should(Succeed())
+		Expect(n).Should(Equal(1))
+		res, err := db.ExecContext(ctx, "UPDATE t SET n = ? WHERE id = ?", 1, 2)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.RowsAffected()).Should(BeEquivalentTo(2))
+		parent.End()
+
+		Expect(recorder).Should(oteltest.HaveSpan("sql.query",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveAttribute("db.system", "mysql"),
+			oteltest.HaveAttribute("db.name", "orders"),
+			oteltest.HaveAttribute("db.statement", "SELECT n FROM t WHERE name = ? AND id = ?"),
+			oteltest.BeChildOf(parent),
+		))
+		Expect(recorder).Should(oteltest.HaveSpan("sql.exec",
+			oteltest.HaveAttribute("db.statement", "UPDATE t SET n = ? WHERE id = ?"),
+			oteltest.HaveAttribute("db.rows_affected", 2),
+			oteltest.HaveStatus(codes.Ok),
+			oteltest.BeChildOf(parent),
+		))
+	})
+
+	It("traces errors", func() {
+		_, err := db.Exec("fail")
+		Expect(err).Should(MatchError(errBadQuery))
+		Expect(recorder).Should(oteltest.HaveSpan("sql.exec", oteltest.HaveStatus(codes.Error, errBadQuery.Error())))
+	})
+
+	It("traces statements and transactions", func() {
+		tx, err := db.BeginTx(context.Background(), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		stmt, err := tx.Prepare("INSERT INTO t VALUES (?)")
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = stmt.Exec(1)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(stmt.Close()).Should(Succeed())
+		Expect(tx.Commit()).Should(Succeed())
+
+		tx, err = db.Begin()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(tx.Rollback()).Should(Succeed())
+
+		var names []string
+		for _, s := range recorder.Spans() {
+			names = append(names, s.Name)
+		}
+		Expect(names).Should(Equal([]string{"sql.begin", "sql.prepare", "sql.exec", "sql.commit", "sql.begin", "sql.rollback"}))
+		Expect(recorder).Should(oteltest.HaveSpan("sql.exec",
+			oteltest.HaveAttribute("db.statement", "INSERT INTO t VALUES (?)"),
+			oteltest.HaveAttribute("db.rows_affected", 1),
+		))
+	})
+
+	It("ends the query spans once the rows are closed", func() {
+		rows, err := db.Query("SELECT n FROM t")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows.Next()).Should(BeTrue())
+		Expect(recorder.Spans()).Should(BeEmpty())
+
+		Expect(rows.Next()).Should(BeFalse())
+		Expect(rows.Close()).Should(Succeed())
+		Expect(recorder).Should(oteltest.HaveSpan("sql.query", oteltest.HaveStatus(codes.Ok)))
+		Expect(recorder.Spans()).Should(HaveLen(1))
+	})
+
+	It("rejects the transaction options the driver can't apply", func() {
+		_, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		Expect(err).Should(MatchError(ContainSubstring("read-only")))
+		_, err = db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+		Expect(err).Should(MatchError(ContainSubstring("isolation level")))
+		Expect(recorder).Should(oteltest.HaveSpan("sql.begin", oteltest.HaveStatus(codes.Error)))
+	})
+
+	It("checks the statement arguments with the conn", func() {
+		checked, err := Open("fake-checker", "")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer checked.Close()
+
+		stmt, err := checked.Prepare("INSERT INTO t VALUES (?)")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer stmt.Close()
+		_, err = stmt.Exec(point{1, 2})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = stmt.Exec(1)
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = stmt.Exec(struct{}{})
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("Register succeed", func() {
+		name, err := Register("fake")
+		Expect(err).ShouldNot(HaveOccurred())
+		again, err := Register("fake")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(again).Should(Equal(name))
+
+		registered, err := sql.Open(name, "")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer registered.Close()
+		_, err = registered.Exec("DELETE FROM t")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(recorder).Should(oteltest.HaveSpan("sql.exec",
+			oteltest.HaveAttribute("db.system", "other_sql"),
+			oteltest.HaveAttribute("db.statement", "DELETE FROM t"),
+		))
+
+		_, err = Register("missing")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("SanitizeSQL", func() {
+		Expect(SanitizeSQL(`SELECT * FROM "t2" WHERE a = 'it''s' AND b = 3.5`)).
+			Should(Equal(`SELECT * FROM "t2" WHERE a = ? AND b = ?`))
+	})
+})
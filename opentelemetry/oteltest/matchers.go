@@ -0,0 +1,288 @@
+package oteltest
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HaveSpan succeeds if a span named name, matching all of matchers, is recorded.
+// The actual value is a *Recorder, tracetest.SpanStubs or []tracetest.SpanStub.
+//
+//	Expect(recorder).Should(HaveSpan("GET /", HaveStatus(codes.Error)))
+func HaveSpan(name string, matchers ...types.GomegaMatcher) types.GomegaMatcher {
+	return &haveSpanMatcher{name: name, matchers: matchers}
+}
+
+// HaveAttribute succeeds if the span has the attribute key, with a value equivalent to value or matching it
+// if value is a matcher. Any value matches if it is omitted.
+// The actual value is a tracetest.SpanStub or *tracetest.SpanStub.
+func HaveAttribute(key string, value ...interface{}) types.GomegaMatcher {
+	m := &haveAttributeMatcher{key: attribute.Key(key)}
+	if len(value) > 0 {
+		if vm, ok := value[0].(types.GomegaMatcher); ok {
+			m.value = vm
+		} else {
+			m.value = gomega.BeEquivalentTo(value[0])
+		}
+	}
+	return m
+}
+
+// HaveStatus succeeds if the span status code is code, and its description is description if one is given.
+// The actual value is a tracetest.SpanStub or *tracetest.SpanStub.
+func HaveStatus(code codes.Code, description ...string) types.GomegaMatcher {
+	m := &haveStatusMatcher{code: code}
+	if len(description) > 0 {
+		m.description = &description[0]
+	}
+	return m
+}
+
+// HaveKind succeeds if the span kind is kind.
+// The actual value is a tracetest.SpanStub or *tracetest.SpanStub.
+func HaveKind(kind trace.SpanKind) types.GomegaMatcher {
+	return &haveKindMatcher{kind: kind}
+}
+
+// BeChildOf succeeds if the span parent is parent, a tracetest.SpanStub, trace.Span or trace.SpanContext.
+// The actual value is a tracetest.SpanStub or *tracetest.SpanStub.
+func BeChildOf(parent interface{}) types.GomegaMatcher {
+	return &beChildOfMatcher{parent: parent}
+}
+
+func toSpans(actual interface{}) (tracetest.SpanStubs, error) {
+	switch v := actual.(type) {
+	case *Recorder:
+		return v.Spans(), nil
+	case tracetest.SpanStubs:
+		return v, nil
+	case []tracetest.SpanStub:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("expected a *Recorder or tracetest.SpanStubs, got:\n%s", format.Object(actual, 1))
+	}
+}
+
+func toSpan(actual interface{}) (tracetest.SpanStub, error) {
+	switch v := actual.(type) {
+	case tracetest.SpanStub:
+		return v, nil
+	case *tracetest.SpanStub:
+		if v != nil {
+			return *v, nil
+		}
+	}
+	return tracetest.SpanStub{}, fmt.Errorf("expected a tracetest.SpanStub, got:\n%s", format.Object(actual, 1))
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+type haveSpanMatcher struct {
+	name     string
+	matchers []types.GomegaMatcher
+
+	spans   tracetest.SpanStubs
+	failure string
+}
+
+func (m *haveSpanMatcher) Match(actual interface{}) (bool, error) {
+	spans, err := toSpans(actual)
+	if err != nil {
+		return false, err
+	}
+	m.spans, m.failure = spans, ""
+
+	for _, s := range spans {
+		if s.Name != m.name {
+			continue
+		}
+		matched := true
+		for _, matcher := range m.matchers {
+			ok, err := matcher.Match(s)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				// keep the reason of the first candidate for the failure message
+				if m.failure == "" {
+					m.failure = matcher.FailureMessage(s)
+				}
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *haveSpanMatcher) FailureMessage(interface{}) string {
+	if m.failure != "" {
+		return fmt.Sprintf("Expected span %q to match, but:\n%s", m.name, m.failure)
+	}
+	return fmt.Sprintf("Expected a span named %q, recorded spans are:\n%s", m.name, format.Object(spanNames(m.spans), 1))
+}
+
+func (m *haveSpanMatcher) NegatedFailureMessage(interface{}) string {
+	return fmt.Sprintf("Expected no matching span named %q, recorded spans are:\n%s", m.name, format.Object(spanNames(m.spans), 1))
+}
+
+type haveAttributeMatcher struct {
+	key   attribute.Key
+	value types.GomegaMatcher
+
+	attrs []attribute.KeyValue
+}
+
+func (m *haveAttributeMatcher) Match(actual interface{}) (bool, error) {
+	span, err := toSpan(actual)
+	if err != nil {
+		return false, err
+	}
+	m.attrs = span.Attributes
+
+	for _, kv := range span.Attributes {
+		if kv.Key != m.key {
+			continue
+		}
+		if m.value == nil {
+			return true, nil
+		}
+		return m.value.Match(kv.Value.AsInterface())
+	}
+	return false, nil
+}
+
+func (m *haveAttributeMatcher) FailureMessage(interface{}) string {
+	if m.value == nil {
+		return format.Message(m.attrs, "to have attribute", string(m.key))
+	}
+	return format.Message(m.attrs, fmt.Sprintf("to have attribute %q", m.key), m.value)
+}
+
+func (m *haveAttributeMatcher) NegatedFailureMessage(interface{}) string {
+	if m.value == nil {
+		return format.Message(m.attrs, "not to have attribute", string(m.key))
+	}
+	return format.Message(m.attrs, fmt.Sprintf("not to have attribute %q", m.key), m.value)
+}
+
+type haveStatusMatcher struct {
+	code        codes.Code
+	description *string
+}
+
+func (m *haveStatusMatcher) Match(actual interface{}) (bool, error) {
+	span, err := toSpan(actual)
+	if err != nil {
+		return false, err
+	}
+	if span.Status.Code != m.code {
+		return false, nil
+	}
+	return m.description == nil || span.Status.Description == *m.description, nil
+}
+
+func (m *haveStatusMatcher) expected() string {
+	if m.description == nil {
+		return m.code.String()
+	}
+	return fmt.Sprintf("%s %q", m.code, *m.description)
+}
+
+func (m *haveStatusMatcher) FailureMessage(actual interface{}) string {
+	span, _ := toSpan(actual)
+	return format.Message(span.Status, "to have status", m.expected())
+}
+
+func (m *haveStatusMatcher) NegatedFailureMessage(actual interface{}) string {
+	span, _ := toSpan(actual)
+	return format.Message(span.Status, "not to have status", m.expected())
+}
+
+type haveKindMatcher struct {
+	kind trace.SpanKind
+}
+
+func (m *haveKindMatcher) Match(actual interface{}) (bool, error) {
+	span, err := toSpan(actual)
+	if err != nil {
+		return false, err
+	}
+	return span.SpanKind == m.kind, nil
+}
+
+func (m *haveKindMatcher) FailureMessage(actual interface{}) string {
+	span, _ := toSpan(actual)
+	return format.Message(span.SpanKind.String(), "to have kind", m.kind.String())
+}
+
+func (m *haveKindMatcher) NegatedFailureMessage(actual interface{}) string {
+	span, _ := toSpan(actual)
+	return format.Message(span.SpanKind.String(), "not to have kind", m.kind.String())
+}
+
+type beChildOfMatcher struct {
+	parent interface{}
+}
+
+func (m *beChildOfMatcher) parentContext() (trace.SpanContext, error) {
+	switch v := m.parent.(type) {
+	case tracetest.SpanStub:
+		return v.SpanContext, nil
+	case *tracetest.SpanStub:
+		if v != nil {
+			return v.SpanContext, nil
+		}
+	case trace.SpanContext:
+		return v, nil
+	case trace.Span:
+		return v.SpanContext(), nil
+	}
+	return trace.SpanContext{}, fmt.Errorf("expected the parent to be a tracetest.SpanStub, trace.Span or trace.SpanContext, got:\n%s", format.Object(m.parent, 1))
+}
+
+func (m *beChildOfMatcher) Match(actual interface{}) (bool, error) {
+	span, err := toSpan(actual)
+	if err != nil {
+		return false, err
+	}
+	parent, err := m.parentContext()
+	if err != nil {
+		return false, err
+	}
+	return parent.IsValid() &&
+		span.Parent.TraceID() == parent.TraceID() &&
+		span.Parent.SpanID() == parent.SpanID(), nil
+}
+
+func (m *beChildOfMatcher) FailureMessage(actual interface{}) string {
+	span, _ := toSpan(actual)
+	parent, _ := m.parentContext()
+	return format.Message(spanContextString(span.Parent), "as parent to equal", spanContextString(parent))
+}
+
+func (m *beChildOfMatcher) NegatedFailureMessage(actual interface{}) string {
+	span, _ := toSpan(actual)
+	parent, _ := m.parentContext()
+	return format.Message(spanContextString(span.Parent), "as parent not to equal", spanContextString(parent))
+}
+
+func spanContextString(sc trace.SpanContext) string {
+	return sc.TraceID().String() + "/" + sc.SpanID().String()
+}
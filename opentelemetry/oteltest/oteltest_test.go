@@ -0,0 +1,84 @@
+package oteltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOteltest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "oteltest Suite")
+}
+
+var _ = Describe("Recorder", func() {
+	var recorder *Recorder
+
+	BeforeEach(func() {
+		recorder = Install()
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("records spans", func() {
+		tracer := otel.Tracer("test")
+		ctx, parent := tracer.Start(context.Background(), "parent", trace.WithSpanKind(trace.SpanKindServer))
+		_, child := tracer.Start(ctx, "child", trace.WithAttributes(attribute.Int("count", 3), attribute.String("name", "value")))
+		child.SetStatus(codes.Error, "failed")
+		child.RecordError(errors.New("failed"))
+		child.End()
+		parent.End()
+
+		Expect(recorder.Spans()).Should(HaveLen(2))
+		Expect(recorder).Should(HaveSpan("parent", HaveKind(trace.SpanKindServer), HaveStatus(codes.Unset)))
+		Expect(recorder).Should(HaveSpan("child",
+			HaveKind(trace.SpanKindInternal),
+			HaveAttribute("count", 3),
+			HaveAttribute("name", HavePrefix("val")),
+			HaveAttribute("name"),
+			HaveStatus(codes.Error, "failed"),
+			BeChildOf(parent),
+		))
+		Expect(recorder).ShouldNot(HaveSpan("child", HaveAttribute("count", 4)))
+		Expect(recorder).ShouldNot(HaveSpan("missing"))
+
+		span, ok := recorder.Span("child")
+		Expect(ok).Should(BeTrue())
+		Expect(span).ShouldNot(HaveAttribute("missing"))
+		Expect(span).ShouldNot(HaveStatus(codes.Error, "other"))
+		parentSpan, _ := recorder.Span("parent")
+		Expect(span).Should(BeChildOf(parentSpan))
+		Expect(parentSpan).ShouldNot(BeChildOf(span))
+
+		recorder.Reset()
+		Expect(recorder.Spans()).Should(BeEmpty())
+	})
+
+	It("restores the global provider", func() {
+		nested := Install()
+		Expect(otel.GetTracerProvider()).Should(BeIdenticalTo(nested.TracerProvider()))
+		nested.Uninstall()
+		Expect(otel.GetTracerProvider()).Should(BeIdenticalTo(recorder.TracerProvider()))
+	})
+
+	It("reports failures", func() {
+		_, span := otel.Tracer("test").Start(context.Background(), "span")
+		span.End()
+
+		matcher := HaveSpan("span", HaveAttribute("count", 1))
+		Expect(matcher.Match(recorder)).Should(BeFalse())
+		Expect(matcher.FailureMessage(recorder)).Should(ContainSubstring(`to have attribute "count"`))
+
+		_, err := HaveSpan("span").Match("not spans")
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,97 @@
+// Package oteltest records the spans of a test in memory to assert the trace shape with Gomega matchers.
+//
+//	var recorder *oteltest.Recorder
+//
+//	BeforeEach(func() {
+//		recorder = oteltest.Install()
+//	})
+//
+//	AfterEach(func() {
+//		recorder.Uninstall()
+//	})
+//
+//	It("traces", func() {
+//		engine := gin.New()
+//		engine.Use(opentelemetry.Tracing("test"))
+//		...
+//		Expect(recorder).Should(oteltest.HaveSpan("/users/:id",
+//			oteltest.HaveKind(trace.SpanKindServer),
+//			oteltest.HaveAttribute("http.status_code", 200),
+//		))
+//	})
+//
+// The global tracer provider has to be installed before the instrumentation gets its tracer,
+// e.g. before opentelemetry.NewTracer or opentelemetry.Tracing are called.
+package oteltest
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recorder keeps every ended span of its tracer provider in memory.
+type Recorder struct {
+	exporter *tracetest.InMemoryExporter
+	provider *tracesdk.TracerProvider
+	saved    trace.TracerProvider
+}
+
+// NewRecorder creates a recorder sampling every span, opts are appended to the provider options.
+func NewRecorder(opts ...tracesdk.TracerProviderOption) *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracesdk.NewTracerProvider(append([]tracesdk.TracerProviderOption{
+		tracesdk.WithSampler(tracesdk.AlwaysSample()),
+		tracesdk.WithSyncer(exporter),
+	}, opts...)...)
+
+	return &Recorder{exporter: exporter, provider: provider}
+}
+
+// Install creates a recorder and sets it as the global tracer provider, Uninstall restores the previous one.
+func Install(opts ...tracesdk.TracerProviderOption) *Recorder {
+	r := NewRecorder(opts...)
+	r.saved = otel.GetTracerProvider()
+	otel.SetTracerProvider(r.provider)
+
+	return r
+}
+
+// Uninstall restores the global tracer provider replaced by Install and shuts the recorder down.
+// The tracers obtained while the recorder was installed stay bound to it and silently drop their spans
+// afterwards, create the instrumentation again after installing the next recorder.
+func (r *Recorder) Uninstall() {
+	if r.saved != nil {
+		otel.SetTracerProvider(r.saved)
+		r.saved = nil
+	}
+	_ = r.provider.Shutdown(context.Background())
+}
+
+// TracerProvider is the provider whose spans are recorded.
+func (r *Recorder) TracerProvider() *tracesdk.TracerProvider {
+	return r.provider
+}
+
+// Reset drops the recorded spans, call it between tests sharing a recorder.
+func (r *Recorder) Reset() {
+	r.exporter.Reset()
+}
+
+// Spans returns the ended spans in the order they ended.
+func (r *Recorder) Spans() tracetest.SpanStubs {
+	return r.exporter.GetSpans()
+}
+
+// Span returns the first ended span named name.
+func (r *Recorder) Span(name string) (tracetest.SpanStub, bool) {
+	for _, s := range r.Spans() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
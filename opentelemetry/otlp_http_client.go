@@ -0,0 +1,430 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultOTLPHTTPEndpoint = "localhost:4318"
+	defaultOTLPHTTPPath     = "/v1/traces"
+)
+
+// OTLPEncoding is the payload encoding of OTLP/HTTP requests
+type OTLPEncoding int
+
+const (
+	// OTLPEncodingProtobuf sends binary protobuf payloads, application/x-protobuf
+	OTLPEncodingProtobuf OTLPEncoding = iota
+	// OTLPEncodingJSON sends JSON payloads, application/json
+	OTLPEncodingJSON
+)
+
+// OTLPHTTPOption is OTLP/HTTP exporter option.
+type OTLPHTTPOption func(*otlpHTTPConfig)
+
+type otlpHTTPConfig struct {
+	endpoint     string
+	urlPath      string
+	insecure     bool
+	tlsConfig    *tls.Config
+	headers      map[string]string
+	encoding     OTLPEncoding
+	gzip         bool
+	timeout      time.Duration
+	retryElapsed time.Duration
+	httpClient   *http.Client
+}
+
+// WithHTTPEndpoint with the collector host and port, "localhost:4318" by default.
+func WithHTTPEndpoint(endpoint string) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithHTTPURLPath with the URL path traces are posted to, "/v1/traces" by default.
+func WithHTTPURLPath(path string) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.urlPath = path
+	}
+}
+
+// WithHTTPInsecure with plain http instead of https.
+func WithHTTPInsecure() OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.insecure = true
+	}
+}
+
+// WithHTTPTLSConfig with the TLS configuration of the https connection.
+func WithHTTPTLSConfig(tlsCfg *tls.Config) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.tlsConfig = tlsCfg
+	}
+}
+
+// WithHTTPHeaders with headers sent with every export, e.g. the authentication token
+// issued by the Aliyun or Tencent Cloud tracing service.
+func WithHTTPHeaders(headers map[string]string) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		for k, v := range headers {
+			c.headers[k] = v
+		}
+	}
+}
+
+// WithHTTPEncoding with the payload encoding, protobuf by default.
+func WithHTTPEncoding(encoding OTLPEncoding) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.encoding = encoding
+	}
+}
+
+// WithHTTPGzip with gzip compressed payloads.
+func WithHTTPGzip() OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.gzip = true
+	}
+}
+
+// WithHTTPTimeout with the max time a single export request may take, 10s by default.
+func WithHTTPTimeout(timeout time.Duration) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPRetry with the max time spent retrying throttled or failed exports, 1m by default.
+// Zero disables retry.
+func WithHTTPRetry(maxElapsedTime time.Duration) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.retryElapsed = maxElapsedTime
+	}
+}
+
+// WithHTTPClient with the http client used for exports, the TLS config is ignored when it is set.
+func WithHTTPClient(client *http.Client) OTLPHTTPOption {
+	return func(c *otlpHTTPConfig) {
+		c.httpClient = client
+	}
+}
+
+// otlpHTTPClient uploads traces to an OTLP/HTTP collector
+type otlpHTTPClient struct {
+	cfg    otlpHTTPConfig
+	url    string
+	client *http.Client
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func newOTLPHTTPClient(options ...OTLPHTTPOption) *otlpHTTPClient {
+	cfg := otlpHTTPConfig{
+		endpoint:     defaultOTLPHTTPEndpoint,
+		urlPath:      defaultOTLPHTTPPath,
+		headers:      map[string]string{},
+		timeout:      10 * time.Second,
+		retryElapsed: time.Minute,
+	}
+	cfg.applyEnv()
+	for _, o := range options {
+		o(&cfg)
+	}
+
+	client := cfg.httpClient
+	if client == nil {
+		client = &http.Client{
+			Transport: &http.Transport{
+				// egress usually goes through HTTPS_PROXY in restricted environments
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: cfg.tlsConfig,
+			},
+		}
+	}
+	scheme := "https"
+	if cfg.insecure {
+		scheme = "http"
+	}
+	u := url.URL{Scheme: scheme, Host: cfg.endpoint, Path: cfg.urlPath}
+
+	return &otlpHTTPClient{cfg: cfg, url: u.String(), client: client, stopCh: make(chan struct{})}
+}
+
+// applyEnv reads the OTEL_EXPORTER_OTLP_* variables, signal specific ones take precedence
+func (c *otlpHTTPConfig) applyEnv() {
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		c.setURL(v, true)
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); v != "" {
+		c.setURL(v, false)
+	}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		for k, v := range parseOTLPHeaders(os.Getenv(name)) {
+			c.headers[k] = v
+		}
+	}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_COMPRESSION", "OTEL_EXPORTER_OTLP_TRACES_COMPRESSION"} {
+		if v := os.Getenv(name); v != "" {
+			c.gzip = v == "gzip"
+		}
+	}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TIMEOUT", "OTEL_EXPORTER_OTLP_TRACES_TIMEOUT"} {
+		// a non-positive timeout would fail every export, keep the default
+		if ms, err := strconv.Atoi(os.Getenv(name)); err == nil && ms > 0 {
+			c.timeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
+
+// setURL applies an endpoint URL, the generic endpoint is a base URL the signal path is appended to
+func (c *otlpHTTPConfig) setURL(raw string, base bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return
+	}
+	c.endpoint = u.Host
+	c.insecure = u.Scheme == "http"
+	if base {
+		c.urlPath = strings.TrimSuffix(u.Path, "/") + defaultOTLPHTTPPath
+	} else if u.Path != "" {
+		c.urlPath = u.Path
+	}
+}
+
+// parseOTLPHeaders parses the url encoded "key1=value1,key2=value2" format
+func parseOTLPHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := cutString(kv, "=")
+		if !ok {
+			continue
+		}
+		k, errK := url.QueryUnescape(strings.TrimSpace(k))
+		v, errV := url.QueryUnescape(strings.TrimSpace(v))
+		if errK != nil || errV != nil || k == "" {
+			continue
+		}
+		headers[k] = v
+	}
+	return headers
+}
+
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// Start does nothing, connections are established on demand
+func (c *otlpHTTPClient) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop aborts pending retries and closes idle connections, it may be called more than once
+func (c *otlpHTTPClient) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// UploadTraces encodes the spans and posts them to the collector, retrying throttled requests
+func (c *otlpHTTPClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	body, err := c.encode(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	backoff := 500 * time.Millisecond
+	for {
+		err := c.send(ctx, body)
+		var re *retryableError
+		if err == nil || !errors.As(err, &re) || c.cfg.retryElapsed <= 0 {
+			return err
+		}
+
+		wait := re.retryAfter
+		if wait < 0 {
+			wait = backoff
+			if backoff *= 2; backoff > 5*time.Second {
+				backoff = 5 * time.Second
+			}
+		}
+		if time.Since(start)+wait > c.cfg.retryElapsed {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-c.stopCh:
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *otlpHTTPClient) encode(req *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	if c.cfg.encoding == OTLPEncodingJSON {
+		body, err = marshalOTLPJSON(req)
+	} else {
+		body, err = proto.Marshal(req)
+	}
+	if err != nil || !c.cfg.gzip {
+		return body, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(body); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *otlpHTTPClient) send(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range c.cfg.headers {
+		req.Header.Set(k, v)
+	}
+	if c.cfg.encoding == OTLPEncodingJSON {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "application/x-protobuf")
+	}
+	if c.cfg.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		// connection errors are transient
+		return &retryableError{err: err, retryAfter: -1}
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		return &retryableError{
+			err:        fmt.Errorf("otlp http export throttled: %s", resp.Status),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	default:
+		return fmt.Errorf("otlp http export failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+}
+
+// retryableError is an export failure that can be retried, retryAfter is negative if the server gave no hint
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// parseRetryAfter parses the delay-seconds or HTTP-date form of the Retry-After header
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return -1
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return -1
+}
+
+// marshalOTLPJSON encodes m as OTLP/JSON, which unlike the canonical protobuf JSON mapping
+// requires integer enums and hex encoded trace and span ids
+func marshalOTLPJSON(m proto.Message) ([]byte, error) {
+	raw, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	hexEncodeIDs(v)
+
+	return json.Marshal(v)
+}
+
+func hexEncodeIDs(v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if s, ok := val.(string); ok && (k == "traceId" || k == "spanId" || k == "parentSpanId") {
+				if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+					t[k] = hex.EncodeToString(b)
+				}
+				continue
+			}
+			hexEncodeIDs(val)
+		}
+	case []interface{}:
+		for _, e := range t {
+			hexEncodeIDs(e)
+		}
+	}
+}
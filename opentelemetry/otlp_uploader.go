@@ -0,0 +1,73 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TracerProviderWithOTLPGRPC use OTLP exporter over gRPC as tracer provider; sdk--grpc-->collector
+// The exporter is configured by the otlptracegrpc options:
+//
+// - otlptracegrpc.WithEndpoint sets the collector address, "localhost:4317" by default.
+// - otlptracegrpc.WithTLSCredentials sets the TLS credentials, otlptracegrpc.WithInsecure disables TLS.
+// - otlptracegrpc.WithHeaders sets the gRPC metadata sent with every export, e.g. authentication tokens.
+// - otlptracegrpc.WithCompressor sets the compressor, only "gzip" is supported.
+// - otlptracegrpc.WithTimeout sets the max time an export may take, 10s by default.
+//
+// This will use the following environment variables for configuration if no explicit option is provided:
+//
+// - OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is the collector endpoint.
+// - OTEL_EXPORTER_OTLP_INSECURE, OTEL_EXPORTER_OTLP_TRACES_INSECURE disables client transport security.
+// - OTEL_EXPORTER_OTLP_CERTIFICATE, OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE is the trusted certificate file.
+// - OTEL_EXPORTER_OTLP_HEADERS, OTEL_EXPORTER_OTLP_TRACES_HEADERS is a list of key=value headers.
+// - OTEL_EXPORTER_OTLP_COMPRESSION, OTEL_EXPORTER_OTLP_TRACES_COMPRESSION is the compressor.
+// - OTEL_EXPORTER_OTLP_TIMEOUT, OTEL_EXPORTER_OTLP_TRACES_TIMEOUT is the export timeout in milliseconds.
+//
+// The passed options will take precedence over any environment variables.
+func TracerProviderWithOTLPGRPC(serverName string, options ...otlptracegrpc.Option) (*tracesdk.TracerProvider, error) {
+	return TracerProviderWithOTLPGRPCOptions(serverName, options)
+}
+
+// TracerProviderWithOTLPGRPCOptions is TracerProviderWithOTLPGRPC with provider options, like WithSampler.
+func TracerProviderWithOTLPGRPCOptions(serverName string, options []otlptracegrpc.Option, opts ...ProviderOption) (*tracesdk.TracerProvider, error) {
+	// Create the OTLP exporter, the connection is established in the background
+	exp, err := otlptracegrpc.New(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTracerProvider(serverName, exp, opts...)
+}
+
+// TracerProviderWithOTLPHTTP use OTLP exporter over HTTP as tracer provider; sdk--http(s)-->collector
+// The payload is protobuf encoded unless WithHTTPEncoding(OTLPEncodingJSON) is passed, requests go
+// through the proxy set by HTTPS_PROXY/HTTP_PROXY. Exports answered with 429, 502, 503 or 504 are
+// retried after the delay given by the Retry-After header, or with exponential backoff without it.
+//
+// This will use the following environment variables for configuration if no explicit option is provided:
+//
+// - OTEL_EXPORTER_OTLP_ENDPOINT is the base URL, "/v1/traces" is appended to it.
+// - OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is the full URL traces are posted to.
+// - OTEL_EXPORTER_OTLP_HEADERS, OTEL_EXPORTER_OTLP_TRACES_HEADERS is a list of key=value headers.
+// - OTEL_EXPORTER_OTLP_COMPRESSION, OTEL_EXPORTER_OTLP_TRACES_COMPRESSION is the compression, "gzip" or "none".
+// - OTEL_EXPORTER_OTLP_TIMEOUT, OTEL_EXPORTER_OTLP_TRACES_TIMEOUT is the export timeout in milliseconds.
+//
+// The passed options will take precedence over any environment variables.
+// If neither values are provided for the endpoint, the default value of "https://localhost:4318/v1/traces" will be used.
+func TracerProviderWithOTLPHTTP(serverName string, options ...OTLPHTTPOption) (*tracesdk.TracerProvider, error) {
+	return TracerProviderWithOTLPHTTPOptions(serverName, options)
+}
+
+// TracerProviderWithOTLPHTTPOptions is TracerProviderWithOTLPHTTP with provider options, like WithSampler.
+func TracerProviderWithOTLPHTTPOptions(serverName string, options []OTLPHTTPOption, opts ...ProviderOption) (*tracesdk.TracerProvider, error) {
+	// Create the OTLP exporter
+	exp, err := otlptrace.New(context.Background(), newOTLPHTTPClient(options...))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTracerProvider(serverName, exp, opts...)
+}
@@ -0,0 +1,225 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcTraceReceiver is an in-process stand-in for an OTLP/gRPC collector
+type grpcTraceReceiver struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	mu      sync.Mutex
+	names   []string
+	headers metadata.MD
+}
+
+func (r *grpcTraceReceiver) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headers, _ = metadata.FromIncomingContext(ctx)
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				r.names = append(r.names, s.GetName())
+			}
+		}
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func (r *grpcTraceReceiver) spanNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+func (r *grpcTraceReceiver) header(key string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.headers.Get(key)
+}
+
+var _ = Describe("OTLP uploader", func() {
+	It("TracerProviderWithOTLPGRPC succeed", func() {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ShouldNot(HaveOccurred())
+		receiver := &grpcTraceReceiver{}
+		srv := grpc.NewServer()
+		coltracepb.RegisterTraceServiceServer(srv, receiver)
+		go func() { _ = srv.Serve(lis) }()
+		defer srv.Stop()
+
+		tp, err := TracerProviderWithOTLPGRPC("otlp-grpc-test",
+			otlptracegrpc.WithEndpoint(lis.Addr().String()),
+			otlptracegrpc.WithInsecure(),
+			otlptracegrpc.WithHeaders(map[string]string{"x-token": "secret"}),
+			otlptracegrpc.WithCompressor("gzip"),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, span := tp.Tracer("test").Start(context.Background(), "otlp-grpc-span")
+		span.End()
+		Expect(tp.Shutdown(context.Background())).Should(Succeed())
+
+		Expect(receiver.spanNames()).Should(ConsistOf("otlp-grpc-span"))
+		Expect(receiver.header("x-token")).Should(ConsistOf("secret"))
+	})
+
+	It("TracerProviderWithOTLPGRPCOptions samples with the provider options", func() {
+		saved := otel.GetTracerProvider()
+		defer otel.SetTracerProvider(saved)
+
+		tp, err := TracerProviderWithOTLPGRPCOptions("otlp-grpc-test",
+			[]otlptracegrpc.Option{otlptracegrpc.WithEndpoint("127.0.0.1:0"), otlptracegrpc.WithInsecure()},
+			WithSampler(tracesdk.NeverSample()), WithDetectors(),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer tp.Shutdown(context.Background())
+
+		_, span := tp.Tracer("test").Start(context.Background(), "otlp-grpc-span")
+		defer span.End()
+		Expect(span.SpanContext().IsSampled()).Should(BeFalse())
+	})
+
+	It("TracerProviderWithOTLPHTTP protobuf succeed", func() {
+		type request struct {
+			path   string
+			header http.Header
+			body   []byte
+		}
+		var (
+			mu       sync.Mutex
+			captured []request
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			captured = append(captured, request{path: r.URL.Path, header: r.Header.Clone(), body: body})
+			if len(captured) == 1 {
+				// throttle the first attempt
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+
+		tp, err := TracerProviderWithOTLPHTTP("otlp-http-test",
+			WithHTTPEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+			WithHTTPInsecure(),
+			WithHTTPHeaders(map[string]string{"Authentication": "token"}),
+			WithHTTPGzip(),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, span := tp.Tracer("test").Start(context.Background(), "otlp-http-span")
+		span.End()
+		Expect(tp.Shutdown(context.Background())).Should(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(captured).Should(HaveLen(2))
+		got := captured[1]
+		Expect(got.path).Should(Equal("/v1/traces"))
+		Expect(got.header.Get("Content-Type")).Should(Equal("application/x-protobuf"))
+		Expect(got.header.Get("Authentication")).Should(Equal("token"))
+		Expect(got.header.Get("Content-Encoding")).Should(Equal("gzip"))
+		gz, err := gzip.NewReader(bytes.NewReader(got.body))
+		Expect(err).ShouldNot(HaveOccurred())
+		body, err := io.ReadAll(gz)
+		Expect(err).ShouldNot(HaveOccurred())
+		req := &coltracepb.ExportTraceServiceRequest{}
+		Expect(proto.Unmarshal(body, req)).Should(Succeed())
+		var names []string
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, s := range ss.GetSpans() {
+					names = append(names, s.GetName())
+				}
+			}
+		}
+		Expect(names).Should(ConsistOf("otlp-http-span"))
+	})
+
+	It("TracerProviderWithOTLPHTTP json succeed", func() {
+		type request struct {
+			contentType string
+			body        []byte
+		}
+		requests := make(chan request, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests <- request{contentType: r.Header.Get("Content-Type"), body: body}
+		}))
+		defer srv.Close()
+
+		tp, err := TracerProviderWithOTLPHTTP("otlp-http-test",
+			WithHTTPEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+			WithHTTPInsecure(),
+			WithHTTPEncoding(OTLPEncodingJSON),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, span := tp.Tracer("test").Start(context.Background(), "otlp-json-span")
+		span.End()
+		Expect(tp.Shutdown(context.Background())).Should(Succeed())
+
+		got := <-requests
+		Expect(got.contentType).Should(Equal("application/json"))
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						Name    string `json:"name"`
+						TraceID string `json:"traceId"`
+						SpanID  string `json:"spanId"`
+						Kind    int    `json:"kind"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		Expect(json.Unmarshal(got.body, &req)).Should(Succeed())
+		s := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+		Expect(s.Name).Should(Equal("otlp-json-span"))
+		Expect(s.TraceID).Should(Equal(span.SpanContext().TraceID().String()))
+		Expect(s.SpanID).Should(Equal(span.SpanContext().SpanID().String()))
+		Expect(s.Kind).Should(Equal(1))
+	})
+
+	It("otlpHTTPClient keeps the default timeout for non-positive env values", func() {
+		defer os.Unsetenv("OTEL_EXPORTER_OTLP_TIMEOUT")
+		for _, v := range []string{"0", "-5"} {
+			os.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", v)
+			Expect(newOTLPHTTPClient().cfg.timeout).Should(Equal(10 * time.Second))
+		}
+		os.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "2500")
+		Expect(newOTLPHTTPClient().cfg.timeout).Should(Equal(2500 * time.Millisecond))
+	})
+
+	It("otlpHTTPClient Stop may be called twice", func() {
+		c := newOTLPHTTPClient()
+		Expect(c.Stop(context.Background())).Should(Succeed())
+		Expect(c.Stop(context.Background())).Should(Succeed())
+	})
+})
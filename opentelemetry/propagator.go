@@ -0,0 +1,70 @@
+package opentelemetry
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// defaultPropagator is the propagator of tracers created without WithPropagator, set by Init
+var defaultPropagator atomic.Value
+
+type propagatorHolder struct {
+	propagator propagation.TextMapPropagator
+}
+
+func getDefaultPropagator() propagation.TextMapPropagator {
+	if h, ok := defaultPropagator.Load().(propagatorHolder); ok {
+		return h.propagator
+	}
+	return propagation.NewCompositeTextMapPropagator(Metadata{}, propagation.Baggage{}, propagation.TraceContext{})
+}
+
+func setDefaultPropagator(propagator propagation.TextMapPropagator) {
+	defaultPropagator.Store(propagatorHolder{propagator: propagator})
+}
+
+// NewPropagator creates a composite propagator from propagator names:
+//
+// - tracecontext: W3C Trace Context.
+// - baggage: W3C Baggage.
+// - b3: B3 single header.
+// - b3multi: B3 multiple headers.
+// - jaeger: jaeger uber-trace-id header.
+// - metadata: the x-md-service-name header, see Metadata.
+// - none: no propagation.
+//
+// No names gives the default metadata, baggage and tracecontext propagators.
+func NewPropagator(names ...string) (propagation.TextMapPropagator, error) {
+	if len(names) == 0 {
+		names = []string{"metadata", "baggage", "tracecontext"}
+	}
+
+	var propagators []propagation.TextMapPropagator
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "tracecontext":
+			propagators = append(propagators, propagation.TraceContext{})
+		case "baggage":
+			propagators = append(propagators, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			propagators = append(propagators, jaeger.Jaeger{})
+		case "metadata":
+			propagators = append(propagators, Metadata{})
+		case "none":
+			return propagation.NewCompositeTextMapPropagator(), nil
+		default:
+			return nil, fmt.Errorf("unsupported propagator: %q", name)
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
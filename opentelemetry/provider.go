@@ -0,0 +1,165 @@
+package opentelemetry
+
+import (
+	"context"
+	"os"
+
+	"github.com/weecloudy/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// ProviderOption is tracer provider option.
+type ProviderOption func(*providerOptions)
+
+type providerOptions struct {
+	sampler    tracesdk.Sampler
+	version    string
+	attributes []attribute.KeyValue
+	batch      []tracesdk.BatchSpanProcessorOption
+	limits     *tracesdk.SpanLimits
+	detectors  []resource.Detector
+	processors []tracesdk.SpanProcessor
+}
+
+// WithSampler with the sampler deciding which traces are recorded, see NewSampler.
+// Without it the sampler is read from OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG,
+// every trace is sampled if they are not set.
+func WithSampler(sampler tracesdk.Sampler) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.sampler = sampler
+	}
+}
+
+// WithServiceVersion with the application version stamped as service.version,
+// it wins over ServiceVersion and the build info of the main module.
+func WithServiceVersion(version string) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.version = version
+	}
+}
+
+// WithResourceAttributes with extra attributes describing the application, they override the default ones.
+func WithResourceAttributes(attrs ...attribute.KeyValue) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.attributes = append(opts.attributes, attrs...)
+	}
+}
+
+// WithDetectors with the resource detectors adding attributes to the service resource, none are used by default:
+//
+//	opentelemetry.WithDetectors(opentelemetry.DefaultDetectors()...)
+//
+// Detection errors are reported to the otel error handler and the detected part is kept.
+func WithDetectors(detectors ...resource.Detector) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.detectors = append(make([]resource.Detector, 0, len(detectors)), detectors...)
+	}
+}
+
+// WithBatchOptions with options tuning the batch span processor,
+// the SDK reads OTEL_BSP_* for the ones not set.
+func WithBatchOptions(batch ...tracesdk.BatchSpanProcessorOption) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.batch = append(opts.batch, batch...)
+	}
+}
+
+// WithSpanLimits with limits on the attributes, events and links a span records,
+// non-positive fields keep the defaults.
+func WithSpanLimits(limits tracesdk.SpanLimits) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.limits = &limits
+	}
+}
+
+// WithSpanProcessor with extra span processors, like NewSpanMetricsProcessor, registered after the batcher.
+func WithSpanProcessor(processors ...tracesdk.SpanProcessor) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.processors = append(opts.processors, processors...)
+	}
+}
+
+// NewTracerProvider batches spans to exp with the service resource and sets it as the global tracer provider,
+// exp may be nil to record spans without exporting them.
+func NewTracerProvider(serverName string, exp tracesdk.SpanExporter, opts ...ProviderOption) (*tracesdk.TracerProvider, error) {
+	op := providerOptions{}
+	for _, o := range opts {
+		o(&op)
+	}
+	if op.sampler == nil {
+		sampler, closeSampler, err := samplerFromEnv(serverName)
+		if err != nil {
+			if exp != nil {
+				_ = exp.Shutdown(context.Background())
+			}
+			return nil, err
+		}
+		op.sampler = sampler
+		if closeSampler != nil {
+			op.processors = append(op.processors, closeOnShutdown(closeSampler))
+		}
+	}
+
+	tpOpts := []tracesdk.TracerProviderOption{
+		tracesdk.WithSampler(op.sampler),
+		tracesdk.WithResource(newResource(serverName, serviceVersion(op.version), op.detectors, op.attributes...)),
+	}
+	if exp != nil {
+		tpOpts = append(tpOpts, tracesdk.WithBatcher(exp, op.batch...))
+	}
+	if op.limits != nil {
+		tpOpts = append(tpOpts, tracesdk.WithSpanLimits(*op.limits))
+	}
+	for _, p := range op.processors {
+		tpOpts = append(tpOpts, tracesdk.WithSpanProcessor(p))
+	}
+	tp := tracesdk.NewTracerProvider(tpOpts...)
+	otel.SetTracerProvider(tp)
+
+	return tp, nil
+}
+
+// closeOnShutdown is a span processor releasing what the provider owns besides its processors, like
+// the polling of a remote sampler
+type closeOnShutdown func()
+
+func (closeOnShutdown) OnStart(context.Context, tracesdk.ReadWriteSpan) {}
+
+func (closeOnShutdown) OnEnd(tracesdk.ReadOnlySpan) {}
+
+func (f closeOnShutdown) Shutdown(context.Context) error {
+	f()
+	return nil
+}
+
+func (closeOnShutdown) ForceFlush(context.Context) error {
+	return nil
+}
+
+// newResource describes the application the spans come from, the service attributes override the detected ones
+// and service.version is left out if version is empty
+func newResource(serverName, version string, detectors []resource.Detector, attrs ...attribute.KeyValue) *resource.Resource {
+	serviceAttrs := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(serverName),
+		attribute.String(logger.RunEnv, os.Getenv(logger.RunEnv)),
+	}
+	if version != "" {
+		serviceAttrs = append(serviceAttrs, semconv.ServiceVersionKey.String(version))
+	}
+	res, err := resource.New(context.Background(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithDetectors(detectors...),
+		resource.WithAttributes(append(serviceAttrs, attrs...)...),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	if res == nil {
+		res = resource.Empty()
+	}
+	return res
+}
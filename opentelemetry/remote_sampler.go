@@ -0,0 +1,410 @@
+package opentelemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	defaultSamplingServerURL       = "http://localhost:5778/sampling"
+	defaultSamplingRefreshInterval = time.Minute
+	defaultSamplingMaxOperations   = 2000
+	defaultInitialSamplingRate     = 0.001
+)
+
+// RemoteSamplerOption is remote sampler option.
+type RemoteSamplerOption func(*remoteSamplerOptions)
+
+type remoteSamplerOptions struct {
+	serverURL       string
+	refreshInterval time.Duration
+	initialSampler  tracesdk.Sampler
+	maxOperations   int
+	httpClient      *http.Client
+}
+
+// WithSamplingServerURL with the jaeger sampling endpoint, "http://localhost:5778/sampling" by default.
+func WithSamplingServerURL(serverURL string) RemoteSamplerOption {
+	return func(opts *remoteSamplerOptions) {
+		opts.serverURL = serverURL
+	}
+}
+
+// WithSamplingRefreshInterval with the polling interval of the sampling endpoint, 1m by default.
+func WithSamplingRefreshInterval(interval time.Duration) RemoteSamplerOption {
+	return func(opts *remoteSamplerOptions) {
+		opts.refreshInterval = interval
+	}
+}
+
+// WithInitialSampler with the sampler used until a strategy is fetched, probabilistic 0.001 by default.
+func WithInitialSampler(sampler tracesdk.Sampler) RemoteSamplerOption {
+	return func(opts *remoteSamplerOptions) {
+		opts.initialSampler = sampler
+	}
+}
+
+// WithSamplingMaxOperations with the max number of operations tracked by per-operation strategies, 2000 by default.
+// Operations beyond the limit share the default sampling probability.
+func WithSamplingMaxOperations(maxOperations int) RemoteSamplerOption {
+	return func(opts *remoteSamplerOptions) {
+		opts.maxOperations = maxOperations
+	}
+}
+
+// WithSamplingHTTPClient with the http client polling the sampling endpoint.
+func WithSamplingHTTPClient(client *http.Client) RemoteSamplerOption {
+	return func(opts *remoteSamplerOptions) {
+		opts.httpClient = client
+	}
+}
+
+// RemoteSampler samples with the strategy served by a jaeger agent or collector for the service,
+// polling GET <server url>?service=<service name> periodically. Probabilistic, rate limiting and
+// per-operation strategies are supported. The initial sampler is used until a strategy is fetched,
+// the last fetched strategy is kept while the endpoint is unreachable.
+//
+// RemoteSampler decides for every span, wrap it with tracesdk.ParentBased to only decide root spans.
+type RemoteSampler struct {
+	serviceName string
+	opts        remoteSamplerOptions
+
+	mu       sync.RWMutex
+	sampler  tracesdk.Sampler
+	strategy *samplingStrategyResponse
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+var _ tracesdk.Sampler = (*RemoteSampler)(nil)
+
+// NewRemoteSampler creates a jaeger remote sampler and starts polling the sampling endpoint
+func NewRemoteSampler(serviceName string, opts ...RemoteSamplerOption) *RemoteSampler {
+	op := remoteSamplerOptions{
+		serverURL:       defaultSamplingServerURL,
+		refreshInterval: defaultSamplingRefreshInterval,
+		initialSampler:  tracesdk.TraceIDRatioBased(defaultInitialSamplingRate),
+		maxOperations:   defaultSamplingMaxOperations,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	s := &RemoteSampler{
+		serviceName: serviceName,
+		opts:        op,
+		sampler:     op.initialSampler,
+		stopCh:      make(chan struct{}),
+	}
+	go s.poll()
+
+	return s
+}
+
+// ShouldSample implements tracesdk.Sampler
+func (s *RemoteSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	s.mu.RLock()
+	sampler := s.sampler
+	s.mu.RUnlock()
+
+	return sampler.ShouldSample(p)
+}
+
+// Description implements tracesdk.Sampler
+func (s *RemoteSampler) Description() string {
+	return fmt.Sprintf("JaegerRemoteSampler{%s}", s.serviceName)
+}
+
+// Close stops polling the sampling endpoint
+func (s *RemoteSampler) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
+func (s *RemoteSampler) poll() {
+	ticker := time.NewTicker(s.opts.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		// errors keep the current sampler, the next tick retries
+		_ = s.updateSampler()
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateSampler fetches the strategy and replaces the sampler if it changed
+func (s *RemoteSampler) updateSampler() error {
+	strategy, err := s.fetchStrategy()
+	if err != nil {
+		return err
+	}
+	sampler, err := s.newStrategySampler(strategy)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// rebuilding an unchanged strategy would reset the rate limiters
+	if s.strategy != nil && strategy.equal(*s.strategy) {
+		return nil
+	}
+	s.sampler = sampler
+	s.strategy = &strategy
+
+	return nil
+}
+
+func (s *RemoteSampler) fetchStrategy() (samplingStrategyResponse, error) {
+	var strategy samplingStrategyResponse
+
+	u, err := url.Parse(s.opts.serverURL)
+	if err != nil {
+		return strategy, err
+	}
+	q := u.Query()
+	q.Set("service", s.serviceName)
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return strategy, err
+	}
+	resp, err := s.opts.httpClient.Do(req)
+	if err != nil {
+		return strategy, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return strategy, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return strategy, fmt.Errorf("sampling strategy request failed: %s: %s", resp.Status, body)
+	}
+	err = json.Unmarshal(body, &strategy)
+
+	return strategy, err
+}
+
+func (s *RemoteSampler) newStrategySampler(strategy samplingStrategyResponse) (tracesdk.Sampler, error) {
+	switch {
+	case strategy.OperationSampling != nil:
+		return newPerOperationSampler(*strategy.OperationSampling, s.opts.maxOperations), nil
+	case strategy.StrategyType == samplingStrategyRateLimiting && strategy.RateLimitingSampling != nil:
+		return RateLimitingSampler(strategy.RateLimitingSampling.MaxTracesPerSecond), nil
+	case strategy.StrategyType == samplingStrategyProbabilistic && strategy.ProbabilisticSampling != nil:
+		return tracesdk.TraceIDRatioBased(strategy.ProbabilisticSampling.SamplingRate), nil
+	default:
+		return nil, fmt.Errorf("unsupported sampling strategy: %+v", strategy)
+	}
+}
+
+// samplingStrategyType is the jaeger strategy type, encoded either as its name or its number
+type samplingStrategyType int
+
+const (
+	samplingStrategyProbabilistic samplingStrategyType = iota
+	samplingStrategyRateLimiting
+)
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *samplingStrategyType) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		switch name {
+		case "PROBABILISTIC":
+			*t = samplingStrategyProbabilistic
+		case "RATE_LIMITING":
+			*t = samplingStrategyRateLimiting
+		default:
+			return fmt.Errorf("unknown sampling strategy type: %q", name)
+		}
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*t = samplingStrategyType(n)
+
+	return nil
+}
+
+type probabilisticSamplingStrategy struct {
+	SamplingRate float64 `json:"samplingRate"`
+}
+
+type rateLimitingSamplingStrategy struct {
+	MaxTracesPerSecond float64 `json:"maxTracesPerSecond"`
+}
+
+type operationSamplingStrategy struct {
+	Operation             string                        `json:"operation"`
+	ProbabilisticSampling probabilisticSamplingStrategy `json:"probabilisticSampling"`
+}
+
+type perOperationSamplingStrategies struct {
+	DefaultSamplingProbability       float64                     `json:"defaultSamplingProbability"`
+	DefaultLowerBoundTracesPerSecond float64                     `json:"defaultLowerBoundTracesPerSecond"`
+	PerOperationStrategies           []operationSamplingStrategy `json:"perOperationStrategies"`
+}
+
+// samplingStrategyResponse is the response of the jaeger sampling endpoint
+type samplingStrategyResponse struct {
+	StrategyType          samplingStrategyType            `json:"strategyType"`
+	ProbabilisticSampling *probabilisticSamplingStrategy  `json:"probabilisticSampling"`
+	RateLimitingSampling  *rateLimitingSamplingStrategy   `json:"rateLimitingSampling"`
+	OperationSampling     *perOperationSamplingStrategies `json:"operationSampling"`
+}
+
+func (r samplingStrategyResponse) equal(o samplingStrategyResponse) bool {
+	a, _ := json.Marshal(r)
+	b, _ := json.Marshal(o)
+	return string(a) == string(b)
+}
+
+// guaranteedThroughputSampler samples probabilistically, but at least lowerBound traces per second
+type guaranteedThroughputSampler struct {
+	probabilistic tracesdk.Sampler
+	lowerBound    tracesdk.Sampler
+}
+
+func newGuaranteedThroughputSampler(samplingRate, lowerBound float64) *guaranteedThroughputSampler {
+	return &guaranteedThroughputSampler{
+		probabilistic: tracesdk.TraceIDRatioBased(samplingRate),
+		lowerBound:    RateLimitingSampler(lowerBound),
+	}
+}
+
+// ShouldSample implements tracesdk.Sampler
+func (s *guaranteedThroughputSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	res := s.probabilistic.ShouldSample(p)
+	// the lower bound always spends its token so sampled traces count against it
+	lowerBound := s.lowerBound.ShouldSample(p)
+	if res.Decision == tracesdk.RecordAndSample {
+		return res
+	}
+	return lowerBound
+}
+
+// Description implements tracesdk.Sampler
+func (s *guaranteedThroughputSampler) Description() string {
+	return fmt.Sprintf("GuaranteedThroughputSampler{%s,%s}", s.probabilistic.Description(), s.lowerBound.Description())
+}
+
+// perOperationSampler samples each span name with its own strategy
+type perOperationSampler struct {
+	strategies    perOperationSamplingStrategies
+	maxOperations int
+	fallback      tracesdk.Sampler
+
+	mu         sync.RWMutex
+	operations map[string]tracesdk.Sampler
+}
+
+func newPerOperationSampler(strategies perOperationSamplingStrategies, maxOperations int) *perOperationSampler {
+	s := &perOperationSampler{
+		strategies:    strategies,
+		maxOperations: maxOperations,
+		fallback:      tracesdk.TraceIDRatioBased(strategies.DefaultSamplingProbability),
+		operations:    map[string]tracesdk.Sampler{},
+	}
+	for _, op := range strategies.PerOperationStrategies {
+		if len(s.operations) >= maxOperations {
+			break
+		}
+		s.operations[op.Operation] = newGuaranteedThroughputSampler(
+			op.ProbabilisticSampling.SamplingRate, strategies.DefaultLowerBoundTracesPerSecond)
+	}
+
+	return s
+}
+
+// ShouldSample implements tracesdk.Sampler
+func (s *perOperationSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	return s.operationSampler(p.Name).ShouldSample(p)
+}
+
+// Description implements tracesdk.Sampler
+func (s *perOperationSampler) Description() string {
+	return fmt.Sprintf("PerOperationSampler{default=%g,lowerBound=%g}",
+		s.strategies.DefaultSamplingProbability, s.strategies.DefaultLowerBoundTracesPerSecond)
+}
+
+// operationSampler returns the sampler of the operation, unlisted operations get the default strategy
+func (s *perOperationSampler) operationSampler(operation string) tracesdk.Sampler {
+	s.mu.RLock()
+	sampler, ok := s.operations[operation]
+	s.mu.RUnlock()
+	if ok {
+		return sampler
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sampler, ok := s.operations[operation]; ok {
+		return sampler
+	}
+	if len(s.operations) >= s.maxOperations {
+		return s.fallback
+	}
+	sampler = newGuaranteedThroughputSampler(s.strategies.DefaultSamplingProbability, s.strategies.DefaultLowerBoundTracesPerSecond)
+	s.operations[operation] = sampler
+
+	return sampler
+}
+
+// remoteSamplerFromArg creates a remote sampler from the OTEL_TRACES_SAMPLER_ARG format of jaeger_remote:
+// "endpoint=http://localhost:5778/sampling,pollingIntervalMs=5000,initialSamplingRate=0.25"
+func remoteSamplerFromArg(serviceName, arg string) (*RemoteSampler, error) {
+	var opts []RemoteSamplerOption
+	for k, v := range parseOTLPHeaders(arg) {
+		switch k {
+		case "endpoint":
+			opts = append(opts, WithSamplingServerURL(v))
+		case "pollingIntervalMs":
+			var ms int64
+			if _, err := fmt.Sscan(v, &ms); err != nil {
+				return nil, fmt.Errorf("invalid pollingIntervalMs %q: %w", v, err)
+			}
+			opts = append(opts, WithSamplingRefreshInterval(time.Duration(ms)*time.Millisecond))
+		case "initialSamplingRate":
+			var rate float64
+			if _, err := fmt.Sscan(v, &rate); err != nil {
+				return nil, fmt.Errorf("invalid initialSamplingRate %q: %w", v, err)
+			}
+			opts = append(opts, WithInitialSampler(tracesdk.TraceIDRatioBased(rate)))
+		default:
+			return nil, fmt.Errorf("unknown jaeger_remote sampler arg: %q", k)
+		}
+	}
+
+	return NewRemoteSampler(serviceName, opts...), nil
+}
@@ -0,0 +1,160 @@
+package opentelemetry
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const defaultCgroupPath = "/proc/self/cgroup"
+
+// downward API env names of the kubernetes attributes, the first set one wins
+var (
+	k8sPodNameEnvs   = []string{"K8S_POD_NAME", "POD_NAME"}
+	k8sPodUIDEnvs    = []string{"K8S_POD_UID", "POD_UID"}
+	k8sNamespaceEnvs = []string{"K8S_NAMESPACE", "POD_NAMESPACE"}
+	k8sNodeNameEnvs  = []string{"K8S_NODE_NAME", "NODE_NAME"}
+)
+
+// containerIDRegexp matches the container id ending a cgroup path, like
+// /kubepods/burstable/pod<uid>/<id> or /system.slice/docker-<id>.scope
+var containerIDRegexp = regexp.MustCompile(`([0-9a-f]{64})(?:\.scope)?$`)
+
+// DefaultDetectors are the host, process, container and Kubernetes detectors, pass them to WithDetectors.
+func DefaultDetectors() []resource.Detector {
+	return []resource.Detector{
+		HostDetector(),
+		ProcessDetector(),
+		ContainerDetector(""),
+		KubernetesDetector(nil),
+	}
+}
+
+type detectorFunc func(ctx context.Context) (*resource.Resource, error)
+
+func (f detectorFunc) Detect(ctx context.Context) (*resource.Resource, error) {
+	return f(ctx)
+}
+
+// HostDetector detects host.name and os.type.
+func HostDetector() resource.Detector {
+	return detectorFunc(func(context.Context) (*resource.Resource, error) {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		return resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.HostNameKey.String(hostname),
+			semconv.OSTypeKey.String(runtime.GOOS),
+		), nil
+	})
+}
+
+// ProcessDetector detects process.pid, process.executable.name and the go runtime.
+func ProcessDetector() resource.Detector {
+	return detectorFunc(func(context.Context) (*resource.Resource, error) {
+		return resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ProcessPIDKey.Int(os.Getpid()),
+			semconv.ProcessExecutableNameKey.String(filepath.Base(os.Args[0])),
+			semconv.ProcessRuntimeNameKey.String("go"),
+			semconv.ProcessRuntimeVersionKey.String(runtime.Version()),
+			semconv.ProcessRuntimeDescriptionKey.String(fmt.Sprintf("go %s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)),
+		), nil
+	})
+}
+
+// ContainerDetector detects container.id from the cgroup file, /proc/self/cgroup if cgroupPath is empty.
+// Nothing is detected outside a container.
+func ContainerDetector(cgroupPath string) resource.Detector {
+	if cgroupPath == "" {
+		cgroupPath = defaultCgroupPath
+	}
+	return detectorFunc(func(context.Context) (*resource.Resource, error) {
+		id, err := containerID(cgroupPath)
+		if err != nil || id == "" {
+			return resource.Empty(), err
+		}
+		return resource.NewWithAttributes(semconv.SchemaURL, semconv.ContainerIDKey.String(id)), nil
+	})
+}
+
+func containerID(cgroupPath string) (string, error) {
+	f, err := os.Open(cgroupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		line := strings.TrimSpace(scanner.Text())
+		if m := containerIDRegexp.FindStringSubmatch(line); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// KubernetesDetector detects k8s.pod.name, k8s.pod.uid, k8s.namespace.name and k8s.node.name
+// from the env vars set with the downward API:
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	- name: POD_NAMESPACE
+//	  valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	- name: NODE_NAME
+//	  valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+//
+// K8S_POD_NAME, K8S_POD_UID, K8S_NAMESPACE, K8S_NODE_NAME and POD_UID are also read,
+// the pod name falls back to HOSTNAME inside a cluster. getenv is os.Getenv if nil.
+func KubernetesDetector(getenv func(string) string) resource.Detector {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	lookup := func(names []string) string {
+		for _, name := range names {
+			if v := getenv(name); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return detectorFunc(func(context.Context) (*resource.Resource, error) {
+		inCluster := getenv("KUBERNETES_SERVICE_HOST") != ""
+		podName := lookup(k8sPodNameEnvs)
+		if podName == "" && inCluster {
+			podName = getenv("HOSTNAME")
+		}
+
+		var attrs []attribute.KeyValue
+		for _, kv := range []attribute.KeyValue{
+			semconv.K8SPodNameKey.String(podName),
+			semconv.K8SPodUIDKey.String(lookup(k8sPodUIDEnvs)),
+			semconv.K8SNamespaceNameKey.String(lookup(k8sNamespaceEnvs)),
+			semconv.K8SNodeNameKey.String(lookup(k8sNodeNameEnvs)),
+		} {
+			if kv.Value.AsString() != "" {
+				attrs = append(attrs, kv)
+			}
+		}
+		if len(attrs) == 0 {
+			return resource.Empty(), nil
+		}
+		return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+	})
+}
@@ -0,0 +1,118 @@
+package opentelemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func detectAttributes(detector resource.Detector) map[attribute.Key]attribute.Value {
+	res, err := detector.Detect(context.Background())
+	Expect(err).ShouldNot(HaveOccurred())
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range res.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+var _ = Describe("Resource", func() {
+	const containerID = "3c4d9f1e2b7a8c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d"
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "resource")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).Should(Succeed())
+	})
+
+	It("ContainerDetector succeed", func() {
+		for _, cgroup := range []string{
+			"12:pids:/kubepods/burstable/pod7d8f/" + containerID + "\n11:memory:/kubepods/burstable/pod7d8f/" + containerID + "\n",
+			"0::/system.slice/docker-" + containerID + ".scope\n",
+			"1:name=systemd:/\n0::/kubepods.slice/cri-containerd-" + containerID + ".scope\n",
+		} {
+			path := filepath.Join(dir, "cgroup")
+			Expect(os.WriteFile(path, []byte(cgroup), 0o600)).Should(Succeed())
+			Expect(detectAttributes(ContainerDetector(path))).Should(HaveKeyWithValue(attribute.Key("container.id"), attribute.StringValue(containerID)))
+		}
+	})
+
+	It("ContainerDetector outside a container", func() {
+		path := filepath.Join(dir, "cgroup")
+		Expect(os.WriteFile(path, []byte("0::/user.slice/user-1000.slice\n"), 0o600)).Should(Succeed())
+		Expect(detectAttributes(ContainerDetector(path))).Should(BeEmpty())
+		Expect(detectAttributes(ContainerDetector(filepath.Join(dir, "missing")))).Should(BeEmpty())
+	})
+
+	It("KubernetesDetector succeed", func() {
+		env := map[string]string{
+			"POD_NAME":      "api-7d8f-x2k",
+			"K8S_NAMESPACE": "prod",
+			"POD_NAMESPACE": "ignored",
+			"NODE_NAME":     "node-1",
+		}
+		Expect(detectAttributes(KubernetesDetector(func(k string) string { return env[k] }))).Should(Equal(map[attribute.Key]attribute.Value{
+			"k8s.pod.name":       attribute.StringValue("api-7d8f-x2k"),
+			"k8s.namespace.name": attribute.StringValue("prod"),
+			"k8s.node.name":      attribute.StringValue("node-1"),
+		}))
+
+		env = map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "HOSTNAME": "api-7d8f-x2k"}
+		Expect(detectAttributes(KubernetesDetector(func(k string) string { return env[k] }))).Should(Equal(map[attribute.Key]attribute.Value{
+			"k8s.pod.name": attribute.StringValue("api-7d8f-x2k"),
+		}))
+
+		env = map[string]string{"HOSTNAME": "laptop"}
+		Expect(detectAttributes(KubernetesDetector(func(k string) string { return env[k] }))).Should(BeEmpty())
+	})
+
+	It("newResource succeed", func() {
+		env := map[string]string{"POD_NAME": "api-7d8f-x2k"}
+		res := newResource("test", "v1.2.3", []resource.Detector{
+			HostDetector(),
+			ProcessDetector(),
+			KubernetesDetector(func(k string) string { return env[k] }),
+		}, attribute.String("k8s.pod.name", "override"))
+
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range res.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		Expect(attrs).Should(HaveKeyWithValue(attribute.Key("service.name"), attribute.StringValue("test")))
+		Expect(attrs).Should(HaveKeyWithValue(attribute.Key("service.version"), attribute.StringValue("v1.2.3")))
+		Expect(attrs).Should(HaveKeyWithValue(attribute.Key("k8s.pod.name"), attribute.StringValue("override")))
+		Expect(attrs).Should(HaveKeyWithValue(attribute.Key("process.pid"), attribute.Int64Value(int64(os.Getpid()))))
+		Expect(attrs).Should(HaveKey(attribute.Key("host.name")))
+		Expect(attrs).Should(HaveKeyWithValue(attribute.Key("process.runtime.name"), attribute.StringValue("go")))
+	})
+
+	It("NewTracerProvider detects nothing by default", func() {
+		saved := otel.GetTracerProvider()
+		defer otel.SetTracerProvider(saved)
+
+		tp, err := NewTracerProvider("test", nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer tp.Shutdown(context.Background())
+
+		_, span := tp.Tracer("test").Start(context.Background(), "span")
+		span.End()
+		res := span.(tracesdk.ReadOnlySpan).Resource()
+		Expect(res.Set().HasValue("service.name")).Should(BeTrue())
+		Expect(res.Set().HasValue("host.name")).Should(BeFalse())
+		Expect(res.Set().HasValue("process.pid")).Should(BeFalse())
+	})
+})
@@ -0,0 +1,177 @@
+package opentelemetry
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	envTracesSampler    = "OTEL_TRACES_SAMPLER"     //sampler type, e.g. parentbased_traceidratio
+	envTracesSamplerArg = "OTEL_TRACES_SAMPLER_ARG" //sampler param, e.g. 0.1
+
+	defaultRateLimitingParam = 100 // traces per second when the rate limiting sampler has no param
+)
+
+// NewSampler creates a sampler by type and param, both the opentracing (jaeger) and
+// the OpenTelemetry sampler names are accepted:
+//
+// - const: sample every trace if param is 1, none if it is 0.
+// - probabilistic, traceidratio: sample a param fraction of traces.
+// - rateLimiting, ratelimiting: sample at most param traces per second.
+// - always_on, always_off: sample every or no span.
+// - parentbased_*: follow the parent decision, root spans are sampled by the named sampler.
+//
+// The jaeger names only decide for root spans like the jaeger client does, children follow their parent.
+// The remote sampler needs the service name, it is created by NewRemoteSampler.
+func NewSampler(samplerType string, param float64) (tracesdk.Sampler, error) {
+	switch samplerType {
+	case "const":
+		return tracesdk.ParentBased(constSampler(param)), nil
+	case "probabilistic":
+		return ParentBasedRatioSampler(param), nil
+	case "rateLimiting":
+		return tracesdk.ParentBased(RateLimitingSampler(param)), nil
+	case "always_on":
+		return tracesdk.AlwaysSample(), nil
+	case "always_off":
+		return tracesdk.NeverSample(), nil
+	case "traceidratio":
+		return tracesdk.TraceIDRatioBased(param), nil
+	case "ratelimiting":
+		return RateLimitingSampler(param), nil
+	case "parentbased_always_on":
+		return tracesdk.ParentBased(tracesdk.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return tracesdk.ParentBased(tracesdk.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return ParentBasedRatioSampler(param), nil
+	case "parentbased_ratelimiting":
+		return tracesdk.ParentBased(RateLimitingSampler(param)), nil
+	default:
+		return nil, fmt.Errorf("unsupported sampler type: %q", samplerType)
+	}
+}
+
+func constSampler(param float64) tracesdk.Sampler {
+	if param >= 1 {
+		return tracesdk.AlwaysSample()
+	}
+	return tracesdk.NeverSample()
+}
+
+// ParentBasedRatioSampler samples a ratio fraction of root spans, children follow their parent
+func ParentBasedRatioSampler(ratio float64) tracesdk.Sampler {
+	return tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio))
+}
+
+// samplerFromEnv creates the sampler named by OTEL_TRACES_SAMPLER, AlwaysSample if it is not set.
+// Besides the NewSampler types jaeger_remote and parentbased_jaeger_remote poll the sampling
+// strategy of the service, see NewRemoteSampler, closeSampler then stops polling. It is nil otherwise.
+func samplerFromEnv(serviceName string) (sampler tracesdk.Sampler, closeSampler func(), err error) {
+	samplerType := strings.TrimSpace(os.Getenv(envTracesSampler))
+	if samplerType == "" {
+		return tracesdk.AlwaysSample(), nil, nil
+	}
+	samplerArg := strings.TrimSpace(os.Getenv(envTracesSamplerArg))
+
+	switch samplerType {
+	case "jaeger_remote", "parentbased_jaeger_remote":
+		remote, err := remoteSamplerFromArg(serviceName, samplerArg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if samplerType == "parentbased_jaeger_remote" {
+			return tracesdk.ParentBased(remote), remote.Close, nil
+		}
+		return remote, remote.Close, nil
+	}
+
+	param := 1.0
+	if strings.HasSuffix(strings.ToLower(samplerType), "ratelimiting") {
+		param = defaultRateLimitingParam
+	}
+	if samplerArg != "" {
+		v, err := strconv.ParseFloat(samplerArg, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s %q: %w", envTracesSamplerArg, samplerArg, err)
+		}
+		param = v
+	}
+
+	sampler, err = NewSampler(samplerType, param)
+	return sampler, nil, err
+}
+
+// RateLimitingSampler samples at most maxTracesPerSecond spans per second with a token bucket,
+// wrap it with tracesdk.ParentBased to limit traces instead of spans.
+func RateLimitingSampler(maxTracesPerSecond float64) tracesdk.Sampler {
+	return newRateLimitingSampler(maxTracesPerSecond, time.Now)
+}
+
+type rateLimitingSampler struct {
+	maxPerSecond float64
+	maxBalance   float64
+	now          func() time.Time
+
+	mu       sync.Mutex
+	balance  float64
+	lastTick time.Time
+}
+
+func newRateLimitingSampler(maxTracesPerSecond float64, now func() time.Time) *rateLimitingSampler {
+	// a fractional rate still needs a whole token to sample, a zero rate never samples
+	maxBalance := 0.0
+	if maxTracesPerSecond > 0 {
+		maxBalance = math.Max(maxTracesPerSecond, 1)
+	}
+	return &rateLimitingSampler{
+		maxPerSecond: maxTracesPerSecond,
+		maxBalance:   maxBalance,
+		now:          now,
+		balance:      maxBalance,
+		lastTick:     now(),
+	}
+}
+
+// ShouldSample implements tracesdk.Sampler
+func (s *rateLimitingSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	decision := tracesdk.Drop
+	if s.take() {
+		decision = tracesdk.RecordAndSample
+	}
+	return tracesdk.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// Description implements tracesdk.Sampler
+func (s *rateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%g}", s.maxPerSecond)
+}
+
+// take refills the bucket for the time elapsed since the last call and spends a token if there is one
+func (s *rateLimitingSampler) take() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.balance += now.Sub(s.lastTick).Seconds() * s.maxPerSecond
+	s.lastTick = now
+	if s.balance > s.maxBalance {
+		s.balance = s.maxBalance
+	}
+	if s.balance < 1 {
+		return false
+	}
+	s.balance--
+	return true
+}
@@ -0,0 +1,185 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func rootSamplingParameters() tracesdk.SamplingParameters {
+	return tracesdk.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       trace.TraceID{1},
+		Name:          "op",
+	}
+}
+
+var _ = Describe("Sampler", func() {
+	It("RateLimitingSampler succeed", func() {
+		now := time.Unix(0, 0)
+		sampler := newRateLimitingSampler(2, func() time.Time { return now })
+
+		decisions := func(n int) (sampled int) {
+			for i := 0; i < n; i++ {
+				if sampler.ShouldSample(rootSamplingParameters()).Decision == tracesdk.RecordAndSample {
+					sampled++
+				}
+			}
+			return sampled
+		}
+		Expect(decisions(5)).Should(Equal(2))
+		now = now.Add(500 * time.Millisecond)
+		Expect(decisions(5)).Should(Equal(1))
+		now = now.Add(10 * time.Second)
+		Expect(decisions(5)).Should(Equal(2))
+	})
+
+	It("NewSampler succeed", func() {
+		for _, typ := range []string{"const", "probabilistic", "rateLimiting", "always_on", "always_off",
+			"traceidratio", "ratelimiting", "parentbased_always_on", "parentbased_always_off",
+			"parentbased_traceidratio", "parentbased_ratelimiting"} {
+			sampler, err := NewSampler(typ, 1)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sampler).ShouldNot(BeNil())
+		}
+		_, err := NewSampler("unknown", 1)
+		Expect(err).Should(HaveOccurred())
+
+		sampler, _ := NewSampler("const", 0)
+		Expect(sampler.ShouldSample(rootSamplingParameters()).Decision).Should(Equal(tracesdk.Drop))
+	})
+
+	It("samplerFromEnv succeed", func() {
+		defer os.Unsetenv(envTracesSampler)
+		defer os.Unsetenv(envTracesSamplerArg)
+
+		sampler, closeSampler, err := samplerFromEnv("test")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sampler.Description()).Should(Equal(tracesdk.AlwaysSample().Description()))
+		Expect(closeSampler).Should(BeNil())
+
+		os.Setenv(envTracesSampler, "parentbased_traceidratio")
+		os.Setenv(envTracesSamplerArg, "0.25")
+		sampler, _, err = samplerFromEnv("test")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sampler.Description()).Should(Equal(ParentBasedRatioSampler(0.25).Description()))
+
+		os.Setenv(envTracesSamplerArg, "abc")
+		_, _, err = samplerFromEnv("test")
+		Expect(err).Should(HaveOccurred())
+
+		os.Setenv(envTracesSampler, "not_jaeger_remote")
+		os.Setenv(envTracesSamplerArg, "")
+		_, _, err = samplerFromEnv("test")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("NewTracerProvider stops polling the remote sampler of the environment on shutdown", func() {
+		defer os.Unsetenv(envTracesSampler)
+		defer os.Unsetenv(envTracesSamplerArg)
+		saved := otel.GetTracerProvider()
+		defer otel.SetTracerProvider(saved)
+
+		var polls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&polls, 1)
+			_, _ = w.Write([]byte(`{"strategyType": "PROBABILISTIC", "probabilisticSampling": {"samplingRate": 1}}`))
+		}))
+		defer srv.Close()
+
+		os.Setenv(envTracesSampler, "parentbased_jaeger_remote")
+		os.Setenv(envTracesSamplerArg, "endpoint="+srv.URL+",pollingIntervalMs=10")
+		tp, err := NewTracerProvider("test", nil, WithDetectors())
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(func() int32 { return atomic.LoadInt32(&polls) }).Should(BeNumerically(">", 1))
+
+		Expect(tp.Shutdown(context.Background())).Should(Succeed())
+		stopped := atomic.LoadInt32(&polls)
+		// a poll in flight at shutdown may still land
+		Consistently(func() int32 { return atomic.LoadInt32(&polls) }, 100*time.Millisecond).Should(BeNumerically("<=", stopped+1))
+	})
+})
+
+var _ = Describe("RemoteSampler", func() {
+	strategies := `{
+		"strategyType": "PROBABILISTIC",
+		"operationSampling": {
+			"defaultSamplingProbability": 0,
+			"defaultLowerBoundTracesPerSecond": 0,
+			"perOperationStrategies": [
+				{"operation": "/always", "probabilisticSampling": {"samplingRate": 1}},
+				{"operation": "/never", "probabilisticSampling": {"samplingRate": 0}}
+			]
+		}
+	}`
+
+	sample := func(sampler tracesdk.Sampler, name string) tracesdk.SamplingDecision {
+		p := rootSamplingParameters()
+		p.Name = name
+		return sampler.ShouldSample(p).Decision
+	}
+
+	It("per-operation strategy succeed", func() {
+		services := make(chan string, 2)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			services <- r.URL.Query().Get("service")
+			_, _ = w.Write([]byte(strategies))
+		}))
+		defer srv.Close()
+
+		sampler := NewRemoteSampler("remote-test",
+			WithSamplingServerURL(srv.URL+"/sampling"),
+			WithSamplingRefreshInterval(time.Hour),
+			WithInitialSampler(tracesdk.NeverSample()),
+		)
+		defer sampler.Close()
+		Expect(sampler.updateSampler()).Should(Succeed())
+
+		Expect(<-services).Should(Equal("remote-test"))
+		Expect(sample(sampler, "/always")).Should(Equal(tracesdk.RecordAndSample))
+		Expect(sample(sampler, "/never")).Should(Equal(tracesdk.Drop))
+		Expect(sample(sampler, "/other")).Should(Equal(tracesdk.Drop))
+	})
+
+	It("rate limiting strategy succeed", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"strategyType": 1, "rateLimitingSampling": {"maxTracesPerSecond": 1}}`))
+		}))
+		defer srv.Close()
+
+		sampler := NewRemoteSampler("remote-test",
+			WithSamplingServerURL(srv.URL),
+			WithSamplingRefreshInterval(time.Hour),
+			WithInitialSampler(tracesdk.NeverSample()),
+		)
+		defer sampler.Close()
+		Expect(sampler.updateSampler()).Should(Succeed())
+
+		Expect(sample(sampler, "op")).Should(Equal(tracesdk.RecordAndSample))
+		Expect(sample(sampler, "op")).Should(Equal(tracesdk.Drop))
+	})
+
+	It("unreachable endpoint falls back to the initial sampler", func() {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		sampler := NewRemoteSampler("remote-test",
+			WithSamplingServerURL(srv.URL),
+			WithSamplingRefreshInterval(time.Hour),
+			WithInitialSampler(tracesdk.AlwaysSample()),
+		)
+		defer sampler.Close()
+		Expect(sampler.updateSampler()).ShouldNot(Succeed())
+
+		Expect(sample(sampler, "op")).Should(Equal(tracesdk.RecordAndSample))
+	})
+})
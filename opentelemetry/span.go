@@ -0,0 +1,52 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithSpan calls fn within an internal span named name, a child of the span of ctx.
+// The error of fn is recorded in the span, a panic is recorded then propagated.
+//
+//	err := opentelemetry.WithSpan(ctx, "charge", func(ctx context.Context) error {
+//		return payments.Charge(ctx, order)
+//	})
+func WithSpan(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...Option) error {
+	_, err := WithSpanResult(ctx, name, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, nil, opts...)
+	return err
+}
+
+// WithSpanResult calls fn within an internal span named name like WithSpan and returns its result,
+// the attributes of resultAttributes are added to the span when fn succeeds. resultAttributes may be nil.
+//
+//	user, err := opentelemetry.WithSpanResult(ctx, "load-user", loadUser, func(u *User) []attribute.KeyValue {
+//		return []attribute.KeyValue{attribute.String("user.id", u.ID)}
+//	})
+func WithSpanResult[T any](ctx context.Context, name string, fn func(ctx context.Context) (T, error),
+	resultAttributes func(result T) []attribute.KeyValue, opts ...Option) (result T, err error) {
+	tracer := NewTracer(trace.SpanKindInternal, opts...)
+	ctx, span := tracer.Start(ctx, name, nil)
+
+	defer func() {
+		if r := recover(); r != nil {
+			span.RecordError(fmt.Errorf("panic: %v", r), trace.WithStackTrace(true))
+			span.SetStatus(codes.Error, fmt.Sprint(r))
+			span.End()
+			panic(r)
+		}
+
+		var kv []attribute.KeyValue
+		if err == nil && resultAttributes != nil {
+			kv = resultAttributes(result)
+		}
+		tracer.End(ctx, span, nil, err, kv...)
+	}()
+
+	return fn(ctx)
+}
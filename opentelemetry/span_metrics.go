@@ -0,0 +1,154 @@
+package opentelemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const defaultCardinalityLimit = 1000
+
+// metrics derived from the ended spans
+const (
+	spanCalls    = "span.calls"
+	spanDuration = "span.duration"
+)
+
+// labels of the span metrics
+const (
+	spanNameKey   = attribute.Key("span.name")
+	spanKindKey   = attribute.Key("span.kind")
+	statusCodeKey = attribute.Key("status.code")
+	// overflowKey marks the measurements of the series over the cardinality limit
+	overflowKey = attribute.Key("otel.metric.overflow")
+)
+
+// SpanMetricsOption is NewSpanMetricsProcessor option.
+type SpanMetricsOption func(*spanMetricsOptions)
+
+type spanMetricsOptions struct {
+	meterProvider metric.MeterProvider
+	limit         int
+}
+
+// WithSpanMetricsMeterProvider with the meter provider recording the span metrics, the global one by default.
+func WithSpanMetricsMeterProvider(mp metric.MeterProvider) SpanMetricsOption {
+	return func(opts *spanMetricsOptions) {
+		opts.meterProvider = mp
+	}
+}
+
+// WithCardinalityLimit with the number of series recorded, 1000 by default or if limit isn't positive.
+// The spans of the series past the limit are recorded in a single overflow series labelled
+// otel.metric.overflow=true.
+func WithCardinalityLimit(limit int) SpanMetricsOption {
+	return func(opts *spanMetricsOptions) {
+		opts.limit = limit
+	}
+}
+
+// NewSpanMetricsProcessor returns a span processor recording the span.calls counter and the span.duration
+// histogram of the ended spans by service.name, span.name, span.kind and status.code, register it to the
+// tracer provider:
+//
+//	tp, err := opentelemetry.NewTracerProvider("my-service", exp,
+//		opentelemetry.WithSpanProcessor(opentelemetry.NewSpanMetricsProcessor()))
+//
+// Span processors only see the sampled spans, the metrics count the calls and latencies of the sampled
+// traces only unless the provider samples every trace, like with the always_on sampler.
+func NewSpanMetricsProcessor(opts ...SpanMetricsOption) tracesdk.SpanProcessor {
+	op := spanMetricsOptions{limit: defaultCardinalityLimit}
+	for _, o := range opts {
+		o(&op)
+	}
+	if op.limit <= 0 {
+		op.limit = defaultCardinalityLimit
+	}
+	if op.meterProvider == nil {
+		op.meterProvider = global.MeterProvider()
+	}
+	meter := op.meterProvider.Meter("weecloudy-tracer", metric.WithInstrumentationVersion(SemVersion()))
+
+	p := &spanMetricsProcessor{
+		limit:  op.limit,
+		series: make(map[attribute.Distinct]struct{}),
+	}
+	var err error
+	if p.calls, err = meter.Int64Counter(spanCalls,
+		instrument.WithUnit("{call}"),
+		instrument.WithDescription("Number of the ended spans")); err != nil {
+		otel.Handle(err)
+	}
+	if p.duration, err = meter.Float64Histogram(spanDuration,
+		instrument.WithUnit(string(unit.Milliseconds)),
+		instrument.WithDescription("Duration of the ended spans")); err != nil {
+		otel.Handle(err)
+	}
+	return p
+}
+
+type spanMetricsProcessor struct {
+	calls    instrument.Int64Counter
+	duration instrument.Float64Histogram
+
+	limit  int
+	mu     sync.Mutex
+	series map[attribute.Distinct]struct{}
+}
+
+var _ tracesdk.SpanProcessor = (*spanMetricsProcessor)(nil)
+
+func (p *spanMetricsProcessor) OnStart(context.Context, tracesdk.ReadWriteSpan) {}
+
+func (p *spanMetricsProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	var service attribute.Value
+	if res := s.Resource(); res != nil {
+		service, _ = res.Set().Value(semconv.ServiceNameKey)
+	}
+	set := attribute.NewSet(
+		semconv.ServiceNameKey.String(service.AsString()),
+		spanNameKey.String(s.Name()),
+		spanKindKey.String(s.SpanKind().String()),
+		statusCodeKey.String(s.Status().Code.String()),
+	)
+	attrs := p.bound(set)
+
+	ctx := context.Background()
+	if p.calls != nil {
+		p.calls.Add(ctx, 1, attrs...)
+	}
+	if p.duration != nil {
+		p.duration.Record(ctx, float64(s.EndTime().Sub(s.StartTime()))/float64(time.Millisecond), attrs...)
+	}
+}
+
+// bound returns the attributes of set, or the overflow ones if it is a new series past the limit
+func (p *spanMetricsProcessor) bound(set attribute.Set) []attribute.KeyValue {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.series[set.Equivalent()]; !ok {
+		if len(p.series) >= p.limit {
+			return []attribute.KeyValue{overflowKey.Bool(true)}
+		}
+		p.series[set.Equivalent()] = struct{}{}
+	}
+	return set.ToSlice()
+}
+
+func (p *spanMetricsProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p *spanMetricsProcessor) ForceFlush(context.Context) error {
+	return nil
+}
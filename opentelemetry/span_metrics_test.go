@@ -0,0 +1,125 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("SpanMetricsProcessor", func() {
+	var (
+		saved  trace.TracerProvider
+		reader sdkmetric.Reader
+		mp     *sdkmetric.MeterProvider
+	)
+
+	BeforeEach(func() {
+		saved = otel.GetTracerProvider()
+		reader = sdkmetric.NewManualReader()
+		mp = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	})
+
+	AfterEach(func() {
+		_ = mp.Shutdown(context.Background())
+		otel.SetTracerProvider(saved)
+	})
+
+	newTracer := func(opts ...SpanMetricsOption) (trace.Tracer, *tracesdk.TracerProvider) {
+		tp, err := NewTracerProvider("span-metrics-test", nil, WithDetectors(),
+			WithSpanProcessor(NewSpanMetricsProcessor(append(opts, WithSpanMetricsMeterProvider(mp))...)))
+		Expect(err).ShouldNot(HaveOccurred())
+		return tp.Tracer("test"), tp
+	}
+
+	It("records the calls and duration of the ended spans", func() {
+		tracer, tp := newTracer()
+		defer tp.Shutdown(context.Background())
+
+		for i := 0; i < 2; i++ {
+			_, span := tracer.Start(context.Background(), "GET /users", trace.WithSpanKind(trace.SpanKindServer))
+			span.End()
+		}
+		_, span := tracer.Start(context.Background(), "query")
+		span.RecordError(errors.New("timeout"))
+		span.SetStatus(codes.Error, "timeout")
+		span.End()
+
+		metrics := collectMetrics(reader)
+		Expect(metrics).Should(HaveKey("span.calls"))
+		calls := metrics["span.calls"].Data.(metricdata.Sum[int64]).DataPoints
+		Expect(calls).Should(ConsistOf(
+			WithTransform(func(dp metricdata.DataPoint[int64]) attribute.Set { return dp.Attributes }, Equal(attribute.NewSet(
+				attribute.String("service.name", "span-metrics-test"),
+				attribute.String("span.name", "GET /users"),
+				attribute.String("span.kind", "server"),
+				attribute.String("status.code", "Unset"),
+			))),
+			WithTransform(func(dp metricdata.DataPoint[int64]) attribute.Set { return dp.Attributes }, Equal(attribute.NewSet(
+				attribute.String("service.name", "span-metrics-test"),
+				attribute.String("span.name", "query"),
+				attribute.String("span.kind", "internal"),
+				attribute.String("status.code", "Error"),
+			))),
+		))
+		for _, dp := range calls {
+			name, _ := dp.Attributes.Value("span.name")
+			if name.AsString() == "query" {
+				Expect(dp.Value).Should(BeEquivalentTo(1))
+			} else {
+				Expect(dp.Value).Should(BeEquivalentTo(2))
+			}
+		}
+
+		Expect(metrics["span.duration"].Unit).Should(BeEquivalentTo("ms"))
+		duration := metrics["span.duration"].Data.(metricdata.Histogram).DataPoints
+		Expect(duration).Should(HaveLen(2))
+	})
+
+	It("records the series past the cardinality limit in the overflow one", func() {
+		tracer, tp := newTracer(WithCardinalityLimit(2))
+		defer tp.Shutdown(context.Background())
+
+		for i := 0; i < 5; i++ {
+			_, span := tracer.Start(context.Background(), "job-"+strconv.Itoa(i))
+			span.End()
+		}
+		// a known series is still recorded past the limit
+		_, span := tracer.Start(context.Background(), "job-0")
+		span.End()
+
+		calls := collectMetrics(reader)["span.calls"].Data.(metricdata.Sum[int64]).DataPoints
+		Expect(calls).Should(HaveLen(3))
+		values := make(map[string]int64)
+		for _, dp := range calls {
+			if dp.Attributes.HasValue("otel.metric.overflow") {
+				values["overflow"] = dp.Value
+				continue
+			}
+			name, _ := dp.Attributes.Value("span.name")
+			values[name.AsString()] = dp.Value
+		}
+		Expect(values).Should(Equal(map[string]int64{"job-0": 2, "job-1": 1, "overflow": 3}))
+	})
+
+	It("uses the default cardinality limit for non-positive ones", func() {
+		tracer, tp := newTracer(WithCardinalityLimit(0))
+		defer tp.Shutdown(context.Background())
+
+		_, span := tracer.Start(context.Background(), "job")
+		span.End()
+
+		calls := collectMetrics(reader)["span.calls"].Data.(metricdata.Sum[int64]).DataPoints
+		Expect(calls).Should(HaveLen(1))
+		Expect(calls[0].Attributes.HasValue("otel.metric.overflow")).Should(BeFalse())
+	})
+})
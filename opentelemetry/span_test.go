@@ -0,0 +1,77 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("WithSpan", func() {
+	var recorder *oteltest.Recorder
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("succeed", func() {
+		ctx, parent := NewTracer(trace.SpanKindServer).Start(context.Background(), "request", nil)
+		Expect(WithSpan(ctx, "work", func(ctx context.Context) error {
+			Expect(trace.SpanFromContext(ctx).SpanContext().SpanID()).ShouldNot(Equal(parent.SpanContext().SpanID()))
+			return nil
+		})).Should(Succeed())
+		Expect(WithSpan(ctx, "fail", func(context.Context) error {
+			return errors.New("failed")
+		})).Should(MatchError("failed"))
+		parent.End()
+
+		Expect(recorder).Should(oteltest.HaveSpan("work",
+			oteltest.HaveKind(trace.SpanKindInternal),
+			oteltest.HaveStatus(codes.Ok),
+			oteltest.BeChildOf(parent),
+		))
+		Expect(recorder).Should(oteltest.HaveSpan("fail", oteltest.HaveStatus(codes.Error, "failed")))
+	})
+
+	It("records the result attributes", func() {
+		resultAttributes := func(n int) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.Int("result", n)}
+		}
+		n, err := WithSpanResult(context.Background(), "count", func(context.Context) (int, error) {
+			return 3, nil
+		}, resultAttributes)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n).Should(Equal(3))
+		_, err = WithSpanResult(context.Background(), "count-fail", func(context.Context) (int, error) {
+			return 0, errors.New("failed")
+		}, resultAttributes)
+		Expect(err).Should(HaveOccurred())
+
+		Expect(recorder).Should(oteltest.HaveSpan("count", oteltest.HaveAttribute("result", 3)))
+		span, ok := recorder.Span("count-fail")
+		Expect(ok).Should(BeTrue())
+		Expect(span.Attributes).Should(BeEmpty())
+	})
+
+	It("records panics", func() {
+		Expect(func() {
+			_ = WithSpan(context.Background(), "panic", func(context.Context) error {
+				panic("boom")
+			})
+		}).Should(PanicWith("boom"))
+
+		Expect(recorder).Should(oteltest.HaveSpan("panic", oteltest.HaveStatus(codes.Error, "boom")))
+		span, _ := recorder.Span("panic")
+		Expect(span.Events[0].Name).Should(Equal("exception"))
+	})
+})
@@ -0,0 +1,439 @@
+package opentelemetry
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultDecisionWait     = 10 * time.Second
+	defaultMaxTraces        = 10000
+	defaultMaxSpansPerTrace = 1000
+)
+
+// metrics of the tail sampling processor
+const (
+	tailSamplingTraces         = "tail_sampling.traces"
+	tailSamplingTracesEvicted  = "tail_sampling.traces.evicted"
+	tailSamplingTracesBuffered = "tail_sampling.traces.buffered"
+	tailSamplingSpansDropped   = "tail_sampling.spans.dropped"
+	tailSamplingSpansLate      = "tail_sampling.spans.late"
+)
+
+// decisionKey is the sampled or dropped decision of the tail_sampling.traces
+const decisionKey = attribute.Key("decision")
+
+// TailSamplingOption is tail sampling processor option.
+type TailSamplingOption func(*tailSamplingOptions)
+
+type tailSamplingOptions struct {
+	decisionWait     time.Duration
+	latencyThreshold time.Duration
+	attributeRules   []attributeRule
+	baseRate         float64
+	maxTraces        int
+	maxSpansPerTrace int
+	meterProvider    metric.MeterProvider
+}
+
+type attributeRule struct {
+	key    attribute.Key
+	values []string
+}
+
+// WithDecisionWait with the time a trace is buffered after its first span ends before it is decided, 10s by default.
+func WithDecisionWait(wait time.Duration) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.decisionWait = wait
+	}
+}
+
+// WithLatencyThreshold keeps traces having a span that took at least threshold.
+func WithLatencyThreshold(threshold time.Duration) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.latencyThreshold = threshold
+	}
+}
+
+// WithAttributeRule keeps traces having a span with the attribute key, set to one of values if any are given.
+func WithAttributeRule(key attribute.Key, values ...string) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.attributeRules = append(opts.attributeRules, attributeRule{key: key, values: values})
+	}
+}
+
+// WithBaseSampleRate with the fraction of traces kept when no rule matched, 0 by default.
+func WithBaseSampleRate(rate float64) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.baseRate = rate
+	}
+}
+
+// WithMaxTraces with the max number of buffered traces, 10000 by default.
+// The oldest trace is decided early when the limit is reached.
+func WithMaxTraces(maxTraces int) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.maxTraces = maxTraces
+	}
+}
+
+// WithMaxSpansPerTrace with the max number of buffered spans of a trace, 1000 by default.
+// Spans beyond the limit are dropped, they still count for the decision.
+func WithMaxSpansPerTrace(maxSpans int) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.maxSpansPerTrace = maxSpans
+	}
+}
+
+// WithTailSamplingMeterProvider with the meter provider recording the processor metrics, the global one by default.
+func WithTailSamplingMeterProvider(mp metric.MeterProvider) TailSamplingOption {
+	return func(opts *tailSamplingOptions) {
+		opts.meterProvider = mp
+	}
+}
+
+// TailSamplingStats is the counters of a tail sampling processor
+type TailSamplingStats struct {
+	TracesSampled  uint64 // traces forwarded to the next processor
+	TracesDropped  uint64 // traces discarded by the decision
+	TracesEvicted  uint64 // traces decided before the decision wait because the buffer was full
+	SpansDropped   uint64 // spans discarded because their trace had too many spans
+	LateSpans      uint64 // spans ended after their trace was decided, they follow the decision
+	BufferedTraces int    // traces waiting for a decision
+}
+
+// TailSamplingProcessor buffers the spans of each trace for the decision wait and forwards the whole
+// trace to the next processor if any span has an error status, exceeds the latency threshold or matches
+// an attribute rule, other traces are kept at the base sample rate.
+//
+// Its counters are exported through the meter provider: tail_sampling.traces by sampled or dropped
+// decision, tail_sampling.traces.evicted, tail_sampling.traces.buffered, tail_sampling.spans.dropped
+// and tail_sampling.spans.late.
+//
+// Spans must be sampled for the processor to see them, use it with the AlwaysSample sampler:
+//
+//	tail := NewTailSamplingProcessor(tracesdk.NewBatchSpanProcessor(exp), WithLatencyThreshold(time.Second))
+//	tp := tracesdk.NewTracerProvider(tracesdk.WithSampler(tracesdk.AlwaysSample()), tracesdk.WithSpanProcessor(tail))
+type TailSamplingProcessor struct {
+	next tracesdk.SpanProcessor
+	opts tailSamplingOptions
+
+	mu           sync.Mutex
+	traces       map[trace.TraceID]*pendingTrace
+	order        *list.List // pending traces by arrival, so also by deadline
+	decided      map[trace.TraceID]bool
+	decidedOrder []trace.TraceID // ring of decided trace ids, bounds the decided map
+	decidedNext  int
+
+	tracesSampled uint64
+	tracesDropped uint64
+	tracesEvicted uint64
+	spansDropped  uint64
+	lateSpans     uint64
+	metrics       metric.Registration
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+type pendingTrace struct {
+	id       trace.TraceID
+	spans    []tracesdk.ReadOnlySpan
+	deadline time.Time
+	keep     bool
+	elem     *list.Element
+}
+
+var _ tracesdk.SpanProcessor = (*TailSamplingProcessor)(nil)
+
+// NewTailSamplingProcessor creates a tail sampling processor forwarding kept traces to next
+func NewTailSamplingProcessor(next tracesdk.SpanProcessor, opts ...TailSamplingOption) *TailSamplingProcessor {
+	op := tailSamplingOptions{
+		decisionWait:     defaultDecisionWait,
+		maxTraces:        defaultMaxTraces,
+		maxSpansPerTrace: defaultMaxSpansPerTrace,
+	}
+	for _, o := range opts {
+		o(&op)
+	}
+	if op.maxTraces < 1 {
+		op.maxTraces = 1
+	}
+
+	p := &TailSamplingProcessor{
+		next:         next,
+		opts:         op,
+		traces:       map[trace.TraceID]*pendingTrace{},
+		order:        list.New(),
+		decided:      map[trace.TraceID]bool{},
+		decidedOrder: make([]trace.TraceID, op.maxTraces),
+		stopCh:       make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	p.metrics = p.registerMetrics(op.meterProvider)
+	go p.run()
+
+	return p
+}
+
+// registerMetrics observes the counters of Stats, the errors are sent to otel.Handle
+func (p *TailSamplingProcessor) registerMetrics(mp metric.MeterProvider) metric.Registration {
+	if mp == nil {
+		mp = global.MeterProvider()
+	}
+	meter := mp.Meter("weecloudy-tracer", metric.WithInstrumentationVersion(SemVersion()))
+
+	traces, err := meter.Int64ObservableCounter(tailSamplingTraces,
+		instrument.WithUnit("{trace}"),
+		instrument.WithDescription("Number of the traces decided by the tail sampling processor"))
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	evicted, err := meter.Int64ObservableCounter(tailSamplingTracesEvicted,
+		instrument.WithUnit("{trace}"),
+		instrument.WithDescription("Number of the traces decided before the decision wait because the buffer was full"))
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	buffered, err := meter.Int64ObservableGauge(tailSamplingTracesBuffered,
+		instrument.WithUnit("{trace}"),
+		instrument.WithDescription("Number of the traces waiting for a decision"))
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	spansDropped, err := meter.Int64ObservableCounter(tailSamplingSpansDropped,
+		instrument.WithUnit("{span}"),
+		instrument.WithDescription("Number of the spans discarded because their trace had too many spans"))
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	lateSpans, err := meter.Int64ObservableCounter(tailSamplingSpansLate,
+		instrument.WithUnit("{span}"),
+		instrument.WithDescription("Number of the spans ended after their trace was decided"))
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+
+	reg, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := p.Stats()
+		o.ObserveInt64(traces, int64(stats.TracesSampled), decisionKey.String("sampled"))
+		o.ObserveInt64(traces, int64(stats.TracesDropped), decisionKey.String("dropped"))
+		o.ObserveInt64(evicted, int64(stats.TracesEvicted))
+		o.ObserveInt64(buffered, int64(stats.BufferedTraces))
+		o.ObserveInt64(spansDropped, int64(stats.SpansDropped))
+		o.ObserveInt64(lateSpans, int64(stats.LateSpans))
+		return nil
+	}, traces, evicted, buffered, spansDropped, lateSpans)
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	return reg
+}
+
+// OnStart implements tracesdk.SpanProcessor
+func (p *TailSamplingProcessor) OnStart(parent context.Context, s tracesdk.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd buffers the span until its trace is decided
+func (p *TailSamplingProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	id := s.SpanContext().TraceID()
+	keep := p.matches(s)
+
+	p.mu.Lock()
+	if sampled, ok := p.decided[id]; ok {
+		p.mu.Unlock()
+		atomic.AddUint64(&p.lateSpans, 1)
+		if sampled {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	var evicted []tracesdk.ReadOnlySpan
+	pt, ok := p.traces[id]
+	if !ok {
+		if len(p.traces) >= p.opts.maxTraces {
+			if oldest := p.order.Front(); oldest != nil {
+				evicted = p.decideLocked(oldest.Value.(*pendingTrace))
+				atomic.AddUint64(&p.tracesEvicted, 1)
+			}
+		}
+		pt = &pendingTrace{id: id, deadline: time.Now().Add(p.opts.decisionWait)}
+		pt.elem = p.order.PushBack(pt)
+		p.traces[id] = pt
+	}
+	pt.keep = pt.keep || keep
+	if len(pt.spans) < p.opts.maxSpansPerTrace {
+		pt.spans = append(pt.spans, s)
+	} else {
+		atomic.AddUint64(&p.spansDropped, 1)
+	}
+	p.mu.Unlock()
+
+	p.forward(evicted)
+}
+
+// Shutdown decides all buffered traces, stops the metrics and shuts down the next processor
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+		<-p.done
+	})
+	p.decideAll()
+	if p.metrics != nil {
+		if err := p.metrics.Unregister(); err != nil {
+			otel.Handle(err)
+		}
+	}
+
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush decides all buffered traces without waiting and flushes the next processor
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.decideAll()
+
+	return p.next.ForceFlush(ctx)
+}
+
+// Stats returns the processor counters
+func (p *TailSamplingProcessor) Stats() TailSamplingStats {
+	p.mu.Lock()
+	buffered := len(p.traces)
+	p.mu.Unlock()
+
+	return TailSamplingStats{
+		TracesSampled:  atomic.LoadUint64(&p.tracesSampled),
+		TracesDropped:  atomic.LoadUint64(&p.tracesDropped),
+		TracesEvicted:  atomic.LoadUint64(&p.tracesEvicted),
+		SpansDropped:   atomic.LoadUint64(&p.spansDropped),
+		LateSpans:      atomic.LoadUint64(&p.lateSpans),
+		BufferedTraces: buffered,
+	}
+}
+
+func (p *TailSamplingProcessor) run() {
+	defer close(p.done)
+
+	interval := p.opts.decisionWait / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case now := <-ticker.C:
+			p.decideExpired(now)
+		}
+	}
+}
+
+func (p *TailSamplingProcessor) decideExpired(now time.Time) {
+	var kept []tracesdk.ReadOnlySpan
+	p.mu.Lock()
+	for e := p.order.Front(); e != nil; e = p.order.Front() {
+		pt := e.Value.(*pendingTrace)
+		if pt.deadline.After(now) {
+			break
+		}
+		kept = append(kept, p.decideLocked(pt)...)
+	}
+	p.mu.Unlock()
+
+	p.forward(kept)
+}
+
+func (p *TailSamplingProcessor) decideAll() {
+	p.decideExpired(time.Now().Add(p.opts.decisionWait))
+}
+
+// decideLocked removes the trace from the buffer and returns its spans if it is kept
+func (p *TailSamplingProcessor) decideLocked(pt *pendingTrace) []tracesdk.ReadOnlySpan {
+	p.order.Remove(pt.elem)
+	delete(p.traces, pt.id)
+
+	sampled := pt.keep || p.baseSampled(pt.id)
+	if old := p.decidedOrder[p.decidedNext]; old.IsValid() {
+		delete(p.decided, old)
+	}
+	p.decidedOrder[p.decidedNext] = pt.id
+	p.decidedNext = (p.decidedNext + 1) % len(p.decidedOrder)
+	p.decided[pt.id] = sampled
+
+	if !sampled {
+		atomic.AddUint64(&p.tracesDropped, 1)
+		return nil
+	}
+	atomic.AddUint64(&p.tracesSampled, 1)
+	return pt.spans
+}
+
+func (p *TailSamplingProcessor) forward(spans []tracesdk.ReadOnlySpan) {
+	for _, s := range spans {
+		p.next.OnEnd(s)
+	}
+}
+
+// matches reports whether the span alone is enough to keep its trace
+func (p *TailSamplingProcessor) matches(s tracesdk.ReadOnlySpan) bool {
+	if s.Status().Code == codes.Error {
+		return true
+	}
+	if p.opts.latencyThreshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.opts.latencyThreshold {
+		return true
+	}
+	for _, rule := range p.opts.attributeRules {
+		for _, kv := range s.Attributes() {
+			if kv.Key == rule.key && rule.match(kv.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r attributeRule) match(v attribute.Value) bool {
+	if len(r.values) == 0 {
+		return true
+	}
+	for _, want := range r.values {
+		if v.Emit() == want {
+			return true
+		}
+	}
+	return false
+}
+
+// baseSampled samples the trace id like tracesdk.TraceIDRatioBased, so every service keeps the same traces
+func (p *TailSamplingProcessor) baseSampled(id trace.TraceID) bool {
+	if p.opts.baseRate >= 1 {
+		return true
+	}
+	bound := uint64(p.opts.baseRate * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:16])>>1 < bound
+}
@@ -0,0 +1,137 @@
+package opentelemetry
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("TailSamplingProcessor", func() {
+	var (
+		exp  *tracetest.InMemoryExporter
+		tail *TailSamplingProcessor
+		tp   *tracesdk.TracerProvider
+	)
+
+	newProvider := func(opts ...TailSamplingOption) {
+		exp = tracetest.NewInMemoryExporter()
+		tail = NewTailSamplingProcessor(tracesdk.NewSimpleSpanProcessor(exp), opts...)
+		tp = tracesdk.NewTracerProvider(tracesdk.WithSampler(tracesdk.AlwaysSample()), tracesdk.WithSpanProcessor(tail))
+	}
+
+	AfterEach(func() {
+		Expect(tp.Shutdown(context.Background())).Should(Succeed())
+	})
+
+	// startTrace records a root span with one child, the child is customized by fn
+	startTrace := func(name string, fn func(span trace.Span)) {
+		ctx, root := tp.Tracer("test").Start(context.Background(), name)
+		_, child := tp.Tracer("test").Start(ctx, name+"-child")
+		fn(child)
+		child.End()
+		root.End()
+	}
+
+	exportedNames := func() []string {
+		var names []string
+		for _, s := range exp.GetSpans() {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	It("keeps error, slow and matching traces", func() {
+		newProvider(WithLatencyThreshold(time.Second), WithAttributeRule("debug", "true"))
+
+		startTrace("ok", func(span trace.Span) {})
+		startTrace("error", func(span trace.Span) { span.SetStatus(codes.Error, "failed") })
+		startTrace("debug", func(span trace.Span) { span.SetAttributes(attribute.Bool("debug", true)) })
+		ctx, slow := tp.Tracer("test").Start(context.Background(), "slow", trace.WithTimestamp(time.Now().Add(-2*time.Second)))
+		_, fast := tp.Tracer("test").Start(ctx, "slow-child")
+		fast.End()
+		slow.End()
+
+		Expect(exp.GetSpans()).Should(BeEmpty())
+		Expect(tail.ForceFlush(context.Background())).Should(Succeed())
+		Expect(exportedNames()).Should(ConsistOf("error", "error-child", "debug", "debug-child", "slow", "slow-child"))
+
+		stats := tail.Stats()
+		Expect(stats.TracesSampled).Should(BeEquivalentTo(3))
+		Expect(stats.TracesDropped).Should(BeEquivalentTo(1))
+		Expect(stats.BufferedTraces).Should(BeZero())
+	})
+
+	It("decides traces after the decision wait", func() {
+		newProvider(WithDecisionWait(20 * time.Millisecond))
+
+		startTrace("error", func(span trace.Span) { span.SetStatus(codes.Error, "failed") })
+		Eventually(exportedNames).Should(ConsistOf("error", "error-child"))
+	})
+
+	It("samples other traces at the base rate", func() {
+		newProvider(WithBaseSampleRate(1))
+
+		startTrace("ok", func(span trace.Span) {})
+		Expect(tail.ForceFlush(context.Background())).Should(Succeed())
+		Expect(exportedNames()).Should(ConsistOf("ok", "ok-child"))
+	})
+
+	It("bounds buffered traces and spans", func() {
+		newProvider(WithMaxTraces(1), WithMaxSpansPerTrace(1))
+
+		startTrace("first", func(span trace.Span) { span.SetStatus(codes.Error, "failed") })
+		startTrace("second", func(span trace.Span) {})
+
+		stats := tail.Stats()
+		Expect(stats.TracesEvicted).Should(BeEquivalentTo(1))
+		Expect(stats.SpansDropped).Should(BeEquivalentTo(2))
+		Expect(stats.BufferedTraces).Should(Equal(1))
+		Expect(exportedNames()).Should(ConsistOf("first-child"))
+	})
+
+	It("records its counters through the meter provider", func() {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		defer mp.Shutdown(context.Background())
+		newProvider(WithMaxTraces(1), WithMaxSpansPerTrace(1), WithTailSamplingMeterProvider(mp))
+
+		startTrace("first", func(span trace.Span) { span.SetStatus(codes.Error, "failed") })
+		startTrace("second", func(span trace.Span) {})
+		Expect(tail.ForceFlush(context.Background())).Should(Succeed())
+
+		metrics := collectMetrics(reader)
+		value := func(name string, attrs ...attribute.KeyValue) int64 {
+			Expect(metrics).Should(HaveKey(name))
+			var points []metricdata.DataPoint[int64]
+			switch data := metrics[name].Data.(type) {
+			case metricdata.Sum[int64]:
+				points = data.DataPoints
+			case metricdata.Gauge[int64]:
+				points = data.DataPoints
+			}
+			set := attribute.NewSet(attrs...)
+			for _, dp := range points {
+				if dp.Attributes.Equals(&set) {
+					return dp.Value
+				}
+			}
+			Fail("no " + name + " data point")
+			return 0
+		}
+		Expect(value("tail_sampling.traces", attribute.String("decision", "sampled"))).Should(BeEquivalentTo(1))
+		Expect(value("tail_sampling.traces", attribute.String("decision", "dropped"))).Should(BeEquivalentTo(1))
+		Expect(value("tail_sampling.traces.evicted")).Should(BeEquivalentTo(1))
+		Expect(value("tail_sampling.traces.buffered")).Should(BeZero())
+		Expect(value("tail_sampling.spans.dropped")).Should(BeEquivalentTo(2))
+		Expect(value("tail_sampling.spans.late")).Should(BeZero())
+	})
+})
@@ -8,6 +8,8 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/proto"
 )
@@ -18,6 +20,7 @@ type Option func(*options)
 type options struct {
 	tracerProvider trace.TracerProvider
 	propagator     propagation.TextMapPropagator
+	link           bool
 }
 
 // WithPropagator with tracer propagator.
@@ -27,6 +30,15 @@ func WithPropagator(propagator propagation.TextMapPropagator) Option {
 	}
 }
 
+// WithLink with consumer spans starting a new trace linked to the extracted remote span
+// instead of continuing it, for messages processed apart from the request producing them.
+// Go spans are linked to the span of their context the same way.
+func WithLink() Option {
+	return func(opts *options) {
+		opts.link = true
+	}
+}
+
 // WithTracerProvider with tracer provider.
 // Deprecated: use otel.SetTracerProvider(provider) instead.
 func WithTracerProvider(provider trace.TracerProvider) Option {
@@ -42,10 +54,14 @@ type Tracer struct {
 	opt    *options
 }
 
-// NewTracer create tracer instance
+// NewTracer create tracer instance, kind decides how Start propagates the span:
+//
+// - Server and Consumer spans extract the remote parent from the incoming carrier.
+// - Client and Producer spans inject into the outgoing carrier.
+// - Internal spans don't propagate, their carrier may be nil.
 func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
 	op := options{
-		propagator: propagation.NewCompositeTextMapPropagator(Metadata{}, propagation.Baggage{}, propagation.TraceContext{}),
+		propagator: getDefaultPropagator(),
 	}
 	for _, o := range opts {
 		o(&op)
@@ -57,48 +73,78 @@ func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
 	}
 
 	switch kind {
-	case trace.SpanKindClient:
-		return &Tracer{tracer: otel.Tracer("weecloudy-tracer", trace.WithInstrumentationVersion(SemVersion())), kind: kind, opt: &op}
-	case trace.SpanKindServer:
+	case trace.SpanKindClient, trace.SpanKindServer, trace.SpanKindInternal, trace.SpanKindProducer, trace.SpanKindConsumer:
 		return &Tracer{tracer: otel.Tracer("weecloudy-tracer", trace.WithInstrumentationVersion(SemVersion())), kind: kind, opt: &op}
 	default:
 		panic(fmt.Sprintf("unsupported span kind: %v", kind))
 	}
 }
 
-// Start start tracing span
+// Start start tracing span, a nil carrier skips the propagation
 func (t *Tracer) Start(ctx context.Context, spanName string, carrier propagation.TextMapCarrier, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
-	if t.kind == trace.SpanKindServer {
+	if carrier != nil && (t.kind == trace.SpanKindServer || t.kind == trace.SpanKindConsumer) {
 		ctx = t.opt.propagator.Extract(ctx, carrier)
+		if t.kind == trace.SpanKindConsumer && t.opt.link {
+			// keep the extracted baggage and metadata, but not the remote parent
+			opts = append(opts, trace.WithNewRoot())
+			if remote := trace.SpanContextFromContext(ctx); remote.IsValid() {
+				opts = append(opts, trace.WithLinks(trace.Link{SpanContext: remote}))
+			}
+		}
 	}
 	ctx, span := t.tracer.Start(ctx,
 		spanName,
 		append(opts, trace.WithSpanKind(t.kind))...,
 	)
-	if t.kind == trace.SpanKindClient {
+	if carrier != nil && (t.kind == trace.SpanKindClient || t.kind == trace.SpanKindProducer) {
 		t.opt.propagator.Inject(ctx, carrier)
 	}
 
 	return ctx, span
 }
 
+// Extract returns ctx with the remote span context read from carrier by the tracer propagator.
+func (t *Tracer) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return t.opt.propagator.Extract(ctx, carrier)
+}
+
 // End finish tracing span
 func (t *Tracer) End(ctx context.Context, span trace.Span, m interface{}, err error, kv ...attribute.KeyValue) {
+	t.end(ctx, span, m, err, true, kv...)
+}
+
+// end finish tracing span, the status stays unset on success if setOk is false
+func (t *Tracer) end(ctx context.Context, span trace.Span, m interface{}, err error, setOk bool, kv ...attribute.KeyValue) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-	} else {
-		span.SetStatus(codes.Ok, "OK")
+	} else if setOk {
+		// Ok overrides Error, keep the error status the caller set from the response
+		if s, ok := span.(tracesdk.ReadOnlySpan); !ok || s.Status().Code != codes.Error {
+			span.SetStatus(codes.Ok, "OK")
+		}
 	}
 
 	span.SetAttributes(kv...)
 
-	// for pb
-	if p, ok := m.(proto.Message); ok {
-		if t.kind == trace.SpanKindServer {
-			span.SetAttributes(attribute.Key("send_msg.size").Int(proto.Size(p)))
-		} else {
-			span.SetAttributes(attribute.Key("recv_msg.size").Int(proto.Size(p)))
+	// for pb and raw messages, server and producer spans send m, client and consumer spans receive it
+	size := -1
+	switch p := m.(type) {
+	case proto.Message:
+		size = proto.Size(p)
+	case []byte:
+		size = len(p)
+	}
+	if size >= 0 {
+		switch t.kind {
+		case trace.SpanKindServer:
+			span.SetAttributes(attribute.Key("send_msg.size").Int(size))
+		case trace.SpanKindClient:
+			span.SetAttributes(attribute.Key("recv_msg.size").Int(size))
+		case trace.SpanKindProducer:
+			span.SetAttributes(attribute.Key("send_msg.size").Int(size), semconv.MessagingMessagePayloadSizeBytesKey.Int(size))
+		case trace.SpanKindConsumer:
+			span.SetAttributes(attribute.Key("recv_msg.size").Int(size), semconv.MessagingMessagePayloadSizeBytesKey.Int(size))
 		}
 	}
 
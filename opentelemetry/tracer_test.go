@@ -19,6 +19,8 @@ import (
 	. "github.com/onsi/gomega"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry/oteltest"
 )
 
 func TestOtel(t *testing.T) {
@@ -36,6 +38,76 @@ var _ = Describe("NewTracer", func() {
 	})
 })
 
+var _ = Describe("Tracer kinds", func() {
+	var recorder *oteltest.Recorder
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+	})
+
+	AfterEach(func() {
+		recorder.Uninstall()
+	})
+
+	It("Internal succeed", func() {
+		tracer := NewTracer(trace.SpanKindInternal)
+		ctx, span := tracer.Start(context.Background(), "internal", nil)
+		Expect(trace.SpanFromContext(ctx)).Should(Equal(span))
+		tracer.End(ctx, span, []byte("data"), nil)
+
+		Expect(recorder).Should(oteltest.HaveSpan("internal", oteltest.HaveKind(trace.SpanKindInternal)))
+		stub, _ := recorder.Span("internal")
+		Expect(stub).ShouldNot(oteltest.HaveAttribute("send_msg.size"))
+		Expect(stub).ShouldNot(oteltest.HaveAttribute("recv_msg.size"))
+	})
+
+	It("Producer and Consumer succeed", func() {
+		headers := propagation.MapCarrier{}
+		producer := NewTracer(trace.SpanKindProducer)
+		ctx, produce := producer.Start(context.Background(), "orders send", headers)
+		producer.End(ctx, produce, []byte("order"), nil)
+		Expect(headers).Should(HaveKey("traceparent"))
+
+		consumer := NewTracer(trace.SpanKindConsumer)
+		ctx, consume := consumer.Start(context.Background(), "orders process", headers)
+		consumer.End(ctx, consume, []byte("order"), nil)
+
+		Expect(recorder).Should(oteltest.HaveSpan("orders send",
+			oteltest.HaveKind(trace.SpanKindProducer),
+			oteltest.HaveAttribute("send_msg.size", 5),
+			oteltest.HaveAttribute("messaging.message_payload_size_bytes", 5),
+		))
+		Expect(recorder).Should(oteltest.HaveSpan("orders process",
+			oteltest.HaveKind(trace.SpanKindConsumer),
+			oteltest.HaveAttribute("recv_msg.size", 5),
+			oteltest.BeChildOf(produce),
+		))
+	})
+
+	It("Consumer WithLink succeed", func() {
+		headers := propagation.MapCarrier{}
+		producer := NewTracer(trace.SpanKindProducer)
+		ctx, produce := producer.Start(context.Background(), "orders send", headers)
+		producer.End(ctx, produce, nil, nil)
+
+		consumer := NewTracer(trace.SpanKindConsumer, WithLink())
+		ctx, consume := consumer.Start(context.Background(), "orders process", headers)
+		consumer.End(ctx, consume, nil, nil)
+
+		stub, ok := recorder.Span("orders process")
+		Expect(ok).Should(BeTrue())
+		Expect(stub).ShouldNot(oteltest.BeChildOf(produce))
+		Expect(stub.Parent.IsValid()).Should(BeFalse())
+		Expect(stub.SpanContext.TraceID()).ShouldNot(Equal(produce.SpanContext().TraceID()))
+		Expect(stub.Links).Should(HaveLen(1))
+		Expect(stub.Links[0].SpanContext.SpanID()).Should(Equal(produce.SpanContext().SpanID()))
+	})
+
+	It("unsupported kind", func() {
+		Expect(func() { NewTracer(trace.SpanKind(100)) }).Should(Panic())
+	})
+})
+
 var _ = Describe("Gin Tracing md", func() {
 	It("TracerProviderWithJaegerCollector succeed", func() {
 		tp, err := TracerProviderWithJaegerCollector("opentelemetry-app-test")
@@ -110,32 +182,25 @@ func homeHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	span := trace.SpanFromContext(ctx)
 
-	syncReq, _ := http.NewRequest("GET", "http://localhost:8080/service", nil)
-	tracer := NewTracer(trace.SpanKindClient)
-	tracer.Start(ctx, "/service", propagation.HeaderCarrier(syncReq.Header), trace.WithSpanKind(trace.SpanKindClient))
-	//otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(syncReq.Header))
-	// if 404 not found don't to trace, no span
-	_, err := http.DefaultClient.Do(syncReq)
-	if err != nil {
+	syncReq, _ := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/service", nil)
+	client := &http.Client{Transport: Transport(nil)}
+	if resp, err := client.Do(syncReq); err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("请求 /service error", err.Error()))
+	} else {
+		resp.Body.Close()
 	}
-	tracer.End(ctx, span, "", err)
-
 	time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
 
-	go func() {
-		//ctx, span = otel.Tracer("home").Start(ctx, "async", trace.WithSpanKind(trace.SpanKindClient))
-		asyncReq, _ := http.NewRequest("GET", "http://localhost:8080/async", nil)
-		tracer.Start(ctx, "/async", propagation.HeaderCarrier(asyncReq.Header), trace.WithSpanKind(trace.SpanKindClient))
-		//otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(asyncReq.Header))
-		_, err := http.DefaultClient.Do(asyncReq)
-		if  err != nil {
-			span.RecordError(err)
-			span.SetAttributes(attribute.String("请求 /async error", err.Error()))
+	// the request span and context end with the request, the background work gets its own
+	Go(ctx, "/async", func(ctx context.Context) error {
+		asyncReq, _ := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/async", nil)
+		resp, err := client.Do(asyncReq)
+		if err != nil {
+			return err
 		}
-		tracer.End(ctx, span, "", err)
-	}()
+		return resp.Body.Close()
+	})
 
 	ctx, span = otel.Tracer("home").Start(ctx, "ping-baidu", trace.WithSpanKind(trace.SpanKindClient))
 	bdReq, _ := http.NewRequest("GET", "https://www.baidu.com", nil)
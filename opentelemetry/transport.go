@@ -0,0 +1,131 @@
+package opentelemetry
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport returns a RoundTripper tracing the requests sent through base, http.DefaultTransport if nil.
+// Every request gets a client span injected into its headers, the span ends when the response body is
+// closed or read to the end, so callers must close it as usual.
+//
+//	client := &http.Client{Transport: opentelemetry.Transport(nil)}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/service", nil)
+//	resp, err := client.Do(req)
+func Transport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, tracer: NewTracer(trace.SpanKindClient, opts...)}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	tracer *Tracer
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request, inject into a copy
+	r := req.Clone(req.Context())
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method, propagation.HeaderCarrier(r.Header),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(req)...),
+		trace.WithAttributes(peerAttributes(req)...),
+	)
+	r = r.WithContext(ctx)
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		t.tracer.End(ctx, span, nil, err)
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(resp.StatusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(resp.StatusCode))
+	if resp.Body == nil || resp.Body == http.NoBody {
+		t.tracer.End(ctx, span, nil, nil)
+		return resp, nil
+	}
+
+	body := &tracedBody{ReadCloser: resp.Body, end: func(n int64, err error) {
+		t.tracer.End(ctx, span, nil, err, semconv.HTTPResponseContentLengthKey.Int64(n))
+	}}
+	if rw, ok := resp.Body.(io.ReadWriteCloser); ok {
+		// keep the body writable for protocol upgrades
+		resp.Body = &tracedReadWriteBody{tracedBody: body, w: rw}
+	} else {
+		resp.Body = body
+	}
+	return resp, nil
+}
+
+// peerAttributes are the net.peer.name and net.peer.port of the request URL
+func peerAttributes(req *http.Request) []attribute.KeyValue {
+	if req.URL == nil || req.URL.Host == "" {
+		return nil
+	}
+	attrs := []attribute.KeyValue{semconv.NetPeerNameKey.String(req.URL.Hostname())}
+
+	port := req.URL.Port()
+	if port == "" {
+		switch req.URL.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.NetPeerPortKey.Int(p))
+	}
+	return attrs
+}
+
+// tracedBody ends the span once the body is read to the end, fails or is closed
+type tracedBody struct {
+	io.ReadCloser
+	end  func(n int64, err error)
+	n    int64
+	once sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	switch err {
+	case nil:
+	case io.EOF:
+		b.finish(nil)
+	default:
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *tracedBody) finish(err error) {
+	b.once.Do(func() {
+		b.end(b.n, err)
+	})
+}
+
+type tracedReadWriteBody struct {
+	*tracedBody
+	w io.Writer
+}
+
+func (b *tracedReadWriteBody) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
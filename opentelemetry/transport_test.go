@@ -0,0 +1,94 @@
+package opentelemetry
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"tracer/opentelemetry/oteltest"
+)
+
+var _ = Describe("Transport", func() {
+	var (
+		recorder *oteltest.Recorder
+		server   *httptest.Server
+		client   *http.Client
+		headers  chan http.Header
+	)
+
+	BeforeEach(func() {
+		recorder = oteltest.Install()
+		headers = make(chan http.Header, 1)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers <- r.Header
+			if r.URL.Path == "/missing" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte("hello"))
+		}))
+		client = &http.Client{Transport: Transport(nil, WithPropagator(propagation.TraceContext{}))}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		recorder.Uninstall()
+	})
+
+	It("succeed", func() {
+		ctx, parent := NewTracer(trace.SpanKindInternal).Start(context.Background(), "parent", nil)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/hello", nil)
+		resp, err := client.Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(req.Header).ShouldNot(HaveKey("Traceparent"))
+		Expect((<-headers).Get("traceparent")).ShouldNot(BeEmpty())
+
+		// ended once the body is closed
+		Expect(recorder.Spans()).Should(BeEmpty())
+		body, _ := ioutil.ReadAll(resp.Body)
+		Expect(string(body)).Should(Equal("hello"))
+		Expect(resp.Body.Close()).Should(Succeed())
+
+		Expect(recorder).Should(oteltest.HaveSpan("HTTP GET",
+			oteltest.HaveKind(trace.SpanKindClient),
+			oteltest.HaveAttribute("http.method", http.MethodGet),
+			oteltest.HaveAttribute("http.url", server.URL+"/hello"),
+			oteltest.HaveAttribute("http.status_code", http.StatusOK),
+			oteltest.HaveAttribute("http.response_content_length", 5),
+			oteltest.HaveAttribute("net.peer.name", "127.0.0.1"),
+			oteltest.HaveAttribute("net.peer.port"),
+			oteltest.HaveStatus(codes.Ok),
+			oteltest.BeChildOf(parent),
+		))
+		Expect(recorder.Spans()).Should(HaveLen(1))
+	})
+
+	It("error status", func() {
+		resp, err := client.Get(server.URL + "/missing")
+		Expect(err).ShouldNot(HaveOccurred())
+		<-headers
+		Expect(resp.Body.Close()).Should(Succeed())
+
+		Expect(recorder).Should(oteltest.HaveSpan("HTTP GET",
+			oteltest.HaveAttribute("http.status_code", http.StatusNotFound),
+			oteltest.HaveStatus(codes.Error),
+		))
+	})
+
+	It("transport error", func() {
+		server.Close()
+		_, err := client.Get(server.URL)
+		Expect(err).Should(HaveOccurred())
+
+		Expect(recorder).Should(oteltest.HaveSpan("HTTP GET", oteltest.HaveStatus(codes.Error)))
+		span, _ := recorder.Span("HTTP GET")
+		Expect(span.Events).ShouldNot(BeEmpty())
+	})
+})
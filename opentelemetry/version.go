@@ -1,11 +1,111 @@
 package opentelemetry
 
-// Version is the current release version of the tracer instrumentation.
+import (
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// defaultVersion is used when the build info has no release version of this module, like in its own tests.
+const defaultVersion = "0.0.1"
+
+// ServiceVersion is the application version stamped as service.version when WithServiceVersion is not used,
+// inject it at build time:
+//
+//	go build -ldflags "-X tracer/opentelemetry.ServiceVersion=v1.2.3"
+//
+// Without it the version of the main module is read from the build info, or its VCS revision for a development build.
+var ServiceVersion string
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version is the current release version of the tracer instrumentation,
+// read from the build info of the application depending on this module.
 func Version() string {
-	return "0.0.1" //git tag version
+	versionOnce.Do(func() {
+		version = defaultVersion
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if v := moduleVersion(bi, reflect.TypeOf(Tracer{}).PkgPath()); v != "" {
+			version = v
+		}
+	})
+	return version
 }
 
 // SemVersion is the semantic version to be supplied to tracer/meter creation.
 func SemVersion() string {
 	return "semver:" + Version()
 }
+
+// moduleVersion returns the version of the module providing pkgPath, empty for a development build
+func moduleVersion(bi *debug.BuildInfo, pkgPath string) string {
+	var found *debug.Module
+	for _, m := range append([]*debug.Module{&bi.Main}, bi.Deps...) {
+		if m.Path == "" || (pkgPath != m.Path && !strings.HasPrefix(pkgPath, m.Path+"/")) {
+			continue
+		}
+		if found == nil || len(m.Path) > len(found.Path) {
+			found = m
+		}
+	}
+	if found == nil {
+		return ""
+	}
+	if found.Replace != nil && found.Replace.Version != "" {
+		found = found.Replace
+	}
+	if found.Version == "(devel)" {
+		return ""
+	}
+	return found.Version
+}
+
+// serviceVersion is the application version, empty if it is unknown
+func serviceVersion(optVersion string) string {
+	if optVersion != "" {
+		return optVersion
+	}
+	if ServiceVersion != "" {
+		return ServiceVersion
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return mainVersion(bi)
+}
+
+// mainVersion is the main module version, or its VCS revision like 0123456789ab-dirty for a development
+// build. Since Go 1.24 the main module version is already stamped from VCS, like a pseudo-version with the
+// revision or a +dirty suffix, and is kept as is.
+func mainVersion(bi *debug.BuildInfo) string {
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+
+	settings := buildSettings(bi)
+	revision := settings["vcs.revision"]
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" && settings["vcs.modified"] == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// buildSettings are the build settings like vcs.revision
+func buildSettings(bi *debug.BuildInfo) map[string]string {
+	settings := make(map[string]string, len(bi.Settings))
+	for _, s := range bi.Settings {
+		settings[s.Key] = s.Value
+	}
+	return settings
+}
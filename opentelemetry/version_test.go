@@ -0,0 +1,75 @@
+package opentelemetry
+
+import (
+	"runtime/debug"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	It("moduleVersion succeed", func() {
+		bi := &debug.BuildInfo{
+			Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "tracer", Version: "v0.3.0"},
+				{Path: "tracer/opentelemetry/sub", Version: "v9.9.9"},
+				{Path: "tracers", Version: "v1.0.0"},
+			},
+		}
+		Expect(moduleVersion(bi, "tracer/opentelemetry")).Should(Equal("v0.3.0"))
+		Expect(moduleVersion(bi, "example.com/app/internal")).Should(BeEmpty())
+		Expect(moduleVersion(bi, "other")).Should(BeEmpty())
+
+		bi.Deps[0].Replace = &debug.Module{Path: "../tracer"}
+		Expect(moduleVersion(bi, "tracer/opentelemetry")).Should(Equal("v0.3.0"))
+		bi.Deps[0].Replace = &debug.Module{Path: "example.com/fork/tracer", Version: "v0.3.1"}
+		Expect(moduleVersion(bi, "tracer/opentelemetry")).Should(Equal("v0.3.1"))
+	})
+
+	It("serviceVersion succeed", func() {
+		defer func(v string) { ServiceVersion = v }(ServiceVersion)
+
+		ServiceVersion = "v2.0.0"
+		Expect(serviceVersion("v1.0.0")).Should(Equal("v1.0.0"))
+		Expect(serviceVersion("")).Should(Equal("v2.0.0"))
+
+		Expect(mainVersion(&debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}})).Should(Equal("v1.2.3"))
+		Expect(mainVersion(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})).Should(BeEmpty())
+		Expect(Version()).ShouldNot(BeEmpty())
+	})
+
+	It("mainVersion with the VCS revision", func() {
+		settings := func(modified string) []debug.BuildSetting {
+			return []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "0123456789abcdef0123456789abcdef01234567"},
+				{Key: "vcs.modified", Value: modified},
+			}
+		}
+		Expect(mainVersion(&debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}, Settings: settings("false")})).
+			Should(Equal("v1.2.3"))
+		Expect(mainVersion(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: settings("false")})).
+			Should(Equal("0123456789ab"))
+		Expect(mainVersion(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: settings("true")})).
+			Should(Equal("0123456789ab-dirty"))
+		Expect(mainVersion(&debug.BuildInfo{Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}}})).
+			Should(Equal("abc123"))
+	})
+
+	It("mainVersion keeps the VCS-stamped versions", func() {
+		settings := []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abcdef1234567890abcdef1234567890abcdef12"},
+			{Key: "vcs.modified", Value: "true"},
+		}
+		Expect(mainVersion(&debug.BuildInfo{
+			Main: debug.Module{Version: "v0.0.0-20261018120000-abcdef123456"}, Settings: settings,
+		})).Should(Equal("v0.0.0-20261018120000-abcdef123456"))
+		Expect(mainVersion(&debug.BuildInfo{
+			Main: debug.Module{Version: "v0.0.0-20261018120000-abcdef123456+dirty"}, Settings: settings,
+		})).Should(Equal("v0.0.0-20261018120000-abcdef123456+dirty"))
+		Expect(mainVersion(&debug.BuildInfo{
+			Main: debug.Module{Version: "v1.2.3+dirty"}, Settings: settings,
+		})).Should(Equal("v1.2.3+dirty"))
+	})
+})